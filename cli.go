@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sientong/reconciliation-service/cli"
+	"github.com/sientong/reconciliation-service/config"
+	impl "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/report"
+	"github.com/sientong/reconciliation-service/validator"
+)
+
+// parseFlags parses the flags of a command. When the command must stop, e.g. after printing --help,
+// it returns false with the exit status.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitSuccess, false
+		}
+		return exitInputFailure, false
+	}
+	return 0, true
+}
+
+// settingsFlags are the flags of the commands reading the settings
+type settingsFlags struct {
+	configFile       string
+	balanceTolerance float64
+}
+
+func (st *settingsFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&st.configFile, "config", "", "config `file`, "+config.FileVariable+" or "+config.DefaultFile+" by default")
+}
+
+// registerInputSettings registers the settings flags of the commands loading input files
+func (st *settingsFlags) registerInputSettings(flags *flag.FlagSet) {
+	flags.Float64Var(&st.balanceTolerance, "balance-tolerance", impl.DefaultBalanceTolerance,
+		"largest `difference` accepted between the expected and the supplied closing balance")
+}
+
+// load reads the settings from the defaults, the config file and the environment, and puts the settings flags
+// given on the command line over them. The other flags of the command are applied by the caller before validate.
+func (st *settingsFlags) load(flags *flag.FlagSet) (*config.Config, error) {
+	cfg, err := config.Load(st.configFile, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	if cli.FlagGiven(flags, "balance-tolerance") {
+		cfg.BalanceTolerance = st.balanceTolerance
+	}
+	return cfg, nil
+}
+
+// applySettings validates the settings and applies those needed to load input files and reconcile them
+func applySettings(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid settings:\n%w", err)
+	}
+
+	for bankName, profile := range cfg.Banks {
+		if err := impl.SetBankAmountFormat(bankName, profile.AmountFormat); err != nil {
+			return err
+		}
+	}
+
+	for column, aliases := range cfg.Headers.Aliases {
+		if err := validator.AddColumnAliases(column, aliases); err != nil {
+			return err
+		}
+	}
+	validator.HeaderCaseSensitive = cfg.Headers.CaseSensitive
+
+	if cfg.ValidationRulesFile != "" {
+		if err := validator.LoadRuleSets(cfg.ValidationRulesFile); err != nil {
+			return err
+		}
+	}
+
+	if cfg.BankBalancesFile != "" {
+		if err := impl.LoadBankBalances(cfg.BankBalancesFile); err != nil {
+			return err
+		}
+	}
+
+	impl.Workers = cfg.Workers
+	impl.BalanceTolerance = cfg.BalanceTolerance
+	if cfg.LoaderWorkers > 0 {
+		impl.LoaderFileWorkers = cfg.LoaderWorkers
+	}
+
+	buckets, err := impl.ParseAgingBuckets(cfg.Aging.Buckets)
+	if err != nil {
+		return err
+	}
+	impl.AgingBuckets = buckets
+
+	return nil
+}
+
+// loadInputs checks the header of every input file and loads their records
+func loadInputs(in cli.Inputs) error {
+	for _, systemFile := range in.SystemFiles {
+		if err := validator.ValidateFile(systemFile, "systemTransaction"); err != nil {
+			return err
+		}
+
+		if err := impl.CreateRecords(systemFile, "systemTransaction", in.Start, in.End); err != nil {
+			return fmt.Errorf("creating transaction records: %w", err)
+		}
+	}
+
+	for _, bankFile := range in.BankFiles {
+		if err := validator.ValidateFile(bankFile, "bankStatement"); err != nil {
+			return err
+		}
+	}
+
+	if err := impl.CreateBankStatementRecords(in.BankFiles, in.Start, in.End); err != nil {
+		return fmt.Errorf("creating bank statement records: %w", err)
+	}
+
+	return nil
+}
+
+// outputFlags are where and how the results are written
+type outputFlags struct {
+	format    string
+	outputDir string
+	htmlFile  string
+	xlsxFile  string
+}
+
+func (out *outputFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&out.format, "format", "", "output `format` of the results, text or json, text by default")
+	flags.StringVar(&out.outputDir, "output-dir", "", "`directory` receiving the csv export of the results")
+	flags.StringVar(&out.htmlFile, "html", "", "`file` receiving the html report of the results")
+	flags.StringVar(&out.xlsxFile, "xlsx", "", "`file` receiving the excel workbook of the results")
+}
+
+// merge puts the output flags given on the command line over the settings, and takes the settings for the others
+func (out *outputFlags) merge(flags *flag.FlagSet, cfg *config.Config) {
+	for name, values := range map[string][2]*string{
+		"format":     {&out.format, &cfg.Output.Format},
+		"output-dir": {&out.outputDir, &cfg.Output.Dir},
+		"html":       {&out.htmlFile, &cfg.Output.HTML},
+		"xlsx":       {&out.xlsxFile, &cfg.Output.XLSX},
+	} {
+		flagValue, setting := values[0], values[1]
+		if cli.FlagGiven(flags, name) {
+			*setting = *flagValue
+		} else {
+			*flagValue = *setting
+		}
+	}
+}
+
+// logs is where progress lines go: stderr in json mode, so stdout only has the json document
+func (out *outputFlags) logs() io.Writer {
+	if out.format == "json" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// write writes the results to stdout and to the requested files, it returns false when one of them failed
+func (out *outputFlags) write(output *model.Output, logs io.Writer) bool {
+	written := true
+
+	if out.format == "json" {
+		if err := report.WriteJSON(os.Stdout, output); err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon writing results:", err)
+			written = false
+		}
+	} else {
+		report.WriteText(os.Stdout, output)
+	}
+
+	if out.outputDir != "" {
+		files, err := report.ExportCSV(out.outputDir, output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon exporting results:", err)
+			written = false
+		}
+		for _, filePath := range files {
+			fmt.Fprintln(logs, "Results written to", filePath)
+		}
+	}
+
+	if out.htmlFile != "" {
+		if err := report.WriteHTML(out.htmlFile, output); err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon writing html report:", err)
+			written = false
+		} else {
+			fmt.Fprintln(logs, "Report written to", out.htmlFile)
+		}
+	}
+
+	if out.xlsxFile != "" {
+		if err := report.WriteXLSX(out.xlsxFile, output); err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon writing excel workbook:", err)
+			written = false
+		} else {
+			fmt.Fprintln(logs, "Workbook written to", out.xlsxFile)
+		}
+	}
+
+	return written
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ProgramName is the name of the program in the usage texts
+const ProgramName = "reconciliation-service"
+
+// Command is a subcommand of the cli, run returns the exit status
+type Command struct {
+	Name    string
+	Summary string
+	Run     func(args []string) int
+}
+
+// ErrNoCommand is returned for an empty command line
+var ErrNoCommand = errors.New("missing command")
+
+// Dispatch finds the command of a command line and returns it with its arguments. A command line starting with
+// a flag or a csv file has no command: it is run by the default command, as it was before there were commands.
+// flag.ErrHelp is returned when the usage is asked for.
+func Dispatch(commands []Command, defaultCommand string, args []string) (Command, []string, error) {
+	if len(args) == 0 {
+		return Command{}, nil, ErrNoCommand
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		return Command{}, nil, flag.ErrHelp
+	}
+
+	for _, cmd := range commands {
+		if cmd.Name == args[0] {
+			return cmd, args[1:], nil
+		}
+	}
+
+	if strings.HasPrefix(args[0], "-") || strings.HasSuffix(args[0], ".csv") {
+		for _, cmd := range commands {
+			if cmd.Name == defaultCommand {
+				return cmd, args, nil
+			}
+		}
+	}
+
+	return Command{}, nil, fmt.Errorf("unknown command %s", args[0])
+}
+
+// Usage prints the usage of the program and its commands
+func Usage(w io.Writer, commands []Command) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [arguments]\n", ProgramName)
+	fmt.Fprintf(w, "       %s [flags] system.csv bank1.csv,bank2.csv [YYYYMMDD YYYYMMDD]\n\n", ProgramName)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> --help' for the flags of a command.\n", ProgramName)
+}
+
+// NewFlagSet creates the flags of a command, with a --help text made of the usage line, the summary and the flags
+func NewFlagSet(name string, arguments string, summary string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [flags] %s\n\n%s\n\nFlags:\n", ProgramName, name, arguments, summary)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// FlagGiven tells whether a flag was set on the command line
+func FlagGiven(flags *flag.FlagSet, name string) bool {
+	given := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			given = true
+		}
+	})
+	return given
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sientong/reconciliation-service/validator"
+)
+
+// FileList is a flag taking files separated by commas, it can be given several times
+type FileList []string
+
+func (l *FileList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *FileList) Set(value string) error {
+	for _, file := range strings.Split(value, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			*l = append(*l, file)
+		}
+	}
+	return nil
+}
+
+// Inputs are the input files and the date range of a run
+type Inputs struct {
+	SystemFiles []string
+	BankFiles   []string
+	Start       string
+	End         string
+	// RangeSource tells where the date range comes from, for the logs
+	RangeSource string
+}
+
+// InputFlags are the flags of the commands reading input files
+type InputFlags struct {
+	systemFiles FileList
+	bankFiles   FileList
+	start       string
+	end         string
+	period      string
+	last        string
+	month       string
+}
+
+func (in *InputFlags) Register(flags *flag.FlagSet) {
+	flags.Var(&in.systemFiles, "system", "system transaction `files`, separated by commas or given several times")
+	flags.Var(&in.bankFiles, "bank", "bank statement `files` named bankName_YYYYMMDD.csv, separated by commas or given several times")
+	flags.StringVar(&in.start, "start", "", "first `date` of the range, YYYYMMDD")
+	flags.StringVar(&in.end, "end", "", "last `date` of the range, YYYYMMDD")
+	flags.StringVar(&in.period, "period", "", "date range `name`: "+strings.Join(validator.Periods, ", "))
+	flags.StringVar(&in.last, "last", "", "date range of the last `days` or weeks before today, e.g. 7d or 2w")
+	flags.StringVar(&in.month, "month", "", "date range of a `month`, YYYY-MM")
+}
+
+// DefaultRange sets the start and end dates left empty, when no relative range is given
+func (in *InputFlags) DefaultRange(start string, end string) {
+	if in.period != "" || in.last != "" || in.month != "" {
+		return
+	}
+	if in.start == "" {
+		in.start = start
+	}
+	if in.end == "" {
+		in.end = end
+	}
+}
+
+// Resolve takes the inputs from the positional arguments "system.csv bank1.csv,bank2.csv [YYYYMMDD YYYYMMDD]"
+// when no input flag is given, turns a relative date range into dates, infers the range from the bank
+// file names when none is given, and validates them
+func (in *InputFlags) Resolve(args []string, today time.Time) (Inputs, error) {
+	if len(in.systemFiles) == 0 && len(in.bankFiles) == 0 && !in.rangeGiven() {
+		return resolveArguments(args)
+	}
+
+	if len(args) > 0 {
+		return Inputs{}, fmt.Errorf("unexpected arguments %s, the input files and dates are given with flags", strings.Join(args, " "))
+	}
+	if len(in.systemFiles) == 0 || len(in.bankFiles) == 0 {
+		return Inputs{}, fmt.Errorf("missing --system or --bank files")
+	}
+
+	inputs, err := in.resolveRange(today)
+	if err != nil {
+		return Inputs{}, err
+	}
+	inputs.SystemFiles, inputs.BankFiles = in.systemFiles, in.bankFiles
+
+	if err := validator.ValidateArgs([]string{in.systemFiles.String(), in.bankFiles.String(), inputs.Start, inputs.End}); err != nil {
+		return Inputs{}, err
+	}
+	return inputs, nil
+}
+
+// resolveArguments takes the inputs from the positional arguments, the date range is inferred from the bank
+// file names when the two dates are left out
+func resolveArguments(args []string) (Inputs, error) {
+	rangeSource := "arguments"
+
+	if len(args) == 2 {
+		var bankFiles FileList
+		bankFiles.Set(args[1])
+		start, end, err := validator.InferDateRange(bankFiles)
+		if err != nil {
+			return Inputs{}, fmt.Errorf("%w, give the start and end dates", err)
+		}
+		args = []string{args[0], args[1], start, end}
+		rangeSource = "bank file names"
+	}
+
+	if err := validator.ValidateArgs(args); err != nil {
+		return Inputs{}, err
+	}
+
+	var systemFiles, bankFiles FileList
+	systemFiles.Set(args[0])
+	bankFiles.Set(args[1])
+	return Inputs{SystemFiles: systemFiles, BankFiles: bankFiles, Start: args[2], End: args[3], RangeSource: rangeSource}, nil
+}
+
+// rangeGiven tells whether a date range was given with flags
+func (in *InputFlags) rangeGiven() bool {
+	return in.start != "" || in.end != "" || in.period != "" || in.last != "" || in.month != ""
+}
+
+// resolveRange returns the start and end dates of the one range flag given, or of the bank file names
+func (in *InputFlags) resolveRange(today time.Time) (Inputs, error) {
+	given := 0
+	for _, value := range []string{in.start + in.end, in.period, in.last, in.month} {
+		if value != "" {
+			given++
+		}
+	}
+	if given > 1 {
+		return Inputs{}, fmt.Errorf("the date range is given with only one of --start and --end, --period, --last or --month")
+	}
+
+	var inputs Inputs
+	var err error
+	switch {
+	case in.start != "" || in.end != "":
+		inputs.Start, inputs.End, inputs.RangeSource = in.start, in.end, "flags"
+	case in.period != "":
+		inputs.Start, inputs.End, err = validator.PeriodRange(in.period, today)
+		inputs.RangeSource = "--period " + in.period
+	case in.last != "":
+		inputs.Start, inputs.End, err = validator.LastRange(in.last, today)
+		inputs.RangeSource = "--last " + in.last
+	case in.month != "":
+		inputs.Start, inputs.End, err = validator.MonthRange(in.month)
+		inputs.RangeSource = "--month " + in.month
+	default:
+		inputs.Start, inputs.End, err = validator.InferDateRange(in.bankFiles)
+		inputs.RangeSource = "bank file names"
+	}
+	return inputs, err
+}
@@ -0,0 +1,375 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	impl "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/util"
+	"github.com/sientong/reconciliation-service/validator"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is read when it exists and no other config file is given
+const DefaultFile = "reconciliation.yaml"
+
+// FileVariable is the environment variable naming the config file
+const FileVariable = "RECONCILIATION_CONFIG"
+
+// Strategies are the names of the reconciliation strategies
+var Strategies = []string{"simple", "concurrent", "indexed"}
+
+// Config is every setting of a run. Settings are layered: the defaults, then the config file, then the
+// environment, then the command line flags.
+type Config struct {
+	Strategy string `yaml:"strategy"`
+	// Workers of the concurrent strategies, 0 is twice the number of CPUs
+	Workers int `yaml:"workers"`
+	// LoaderWorkers are the bank statement files loaded at the same time, 0 is the number of CPUs
+	LoaderWorkers       int                    `yaml:"loader_workers"`
+	Banks               map[string]BankProfile `yaml:"banks"`
+	Headers             Headers                `yaml:"headers"`
+	ValidationRulesFile string                 `yaml:"validation_rules_file"`
+	BankBalancesFile    string                 `yaml:"bank_balances_file"`
+	// BalanceTolerance is the largest difference accepted between the expected and the supplied closing balance
+	BalanceTolerance float64                     `yaml:"balance_tolerance"`
+	FingerprintStore string                      `yaml:"fingerprint_store"`
+	Output           Output                      `yaml:"output"`
+	DataQuality      model.DataQualityThresholds `yaml:"data_quality"`
+	Discrepancy      model.DiscrepancyThresholds `yaml:"discrepancy"`
+	Aging            Aging                       `yaml:"aging"`
+
+	// File is the config file which was read, empty when there was none
+	File string `yaml:"-"`
+}
+
+// BankProfile are the settings of a bank, by bank name
+type BankProfile struct {
+	AmountFormat string `yaml:"amount_format"`
+}
+
+// Headers are the settings used to find the columns of the input files
+type Headers struct {
+	// Aliases are other names accepted for a column, by column
+	Aliases       map[string][]string `yaml:"aliases"`
+	CaseSensitive bool                `yaml:"case_sensitive"`
+}
+
+// Output is where and how the results are written
+type Output struct {
+	Format              string `yaml:"format"`
+	Dir                 string `yaml:"dir"`
+	HTML                string `yaml:"html"`
+	XLSX                string `yaml:"xlsx"`
+	RejectedRecordsFile string `yaml:"rejected_records_file"`
+	MatchedPairsFile    string `yaml:"matched_pairs_file"`
+}
+
+// Aging are the settings of the aging of unmatched items
+type Aging struct {
+	Buckets string `yaml:"buckets"`
+	// ReferenceDate is YYYYMMDD, the end date of the run when empty
+	ReferenceDate string `yaml:"reference_date"`
+}
+
+// Defaults returns the settings used when nothing else is set
+func Defaults() *Config {
+	return &Config{
+		Strategy:         "concurrent",
+		BalanceTolerance: impl.DefaultBalanceTolerance,
+		Output:           Output{Format: "text"},
+		Aging:            Aging{Buckets: impl.DefaultAgingBuckets},
+	}
+}
+
+// Load reads the settings from the defaults, the config file and the environment. The config file is path,
+// or the file named by RECONCILIATION_CONFIG, or reconciliation.yaml when it exists.
+func Load(path string, getenv func(string) string) (*Config, error) {
+	cfg := Defaults()
+
+	explicit := true
+	if path == "" {
+		path = getenv(FileVariable)
+	}
+	if path == "" {
+		path, explicit = DefaultFile, false
+	}
+
+	if err := cfg.readFile(path); err != nil {
+		if explicit || !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	} else {
+		cfg.File = path
+	}
+
+	if err := cfg.readEnv(getenv); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// readFile reads the config file over the current settings, unknown keys are an error
+func (cfg *Config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("read config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// readEnv reads the environment variables over the current settings, unset or empty variables are skipped
+func (cfg *Config) readEnv(getenv func(string) string) error {
+	settings := map[string]*string{
+		"RECONCILLIATION_STRATEGY": &cfg.Strategy,
+		"VALIDATION_RULES_FILE":    &cfg.ValidationRulesFile,
+		"BANK_BALANCES_FILE":       &cfg.BankBalancesFile,
+		"FINGERPRINT_STORE":        &cfg.FingerprintStore,
+		"OUTPUT_FORMAT":            &cfg.Output.Format,
+		"OUTPUT_DIR":               &cfg.Output.Dir,
+		"HTML_REPORT_FILE":         &cfg.Output.HTML,
+		"XLSX_REPORT_FILE":         &cfg.Output.XLSX,
+		"REJECTED_RECORDS_FILE":    &cfg.Output.RejectedRecordsFile,
+		"MATCHED_PAIRS_FILE":       &cfg.Output.MatchedPairsFile,
+		"AGING_BUCKETS":            &cfg.Aging.Buckets,
+		"AGING_REFERENCE_DATE":     &cfg.Aging.ReferenceDate,
+	}
+	for name, target := range settings {
+		if value := getenv(name); value != "" {
+			*target = value
+		}
+	}
+
+	for name, target := range map[string]*int{"RECONCILIATION_WORKERS": &cfg.Workers, "LOADER_WORKERS": &cfg.LoaderWorkers} {
+		if value := getenv(name); value != "" {
+			workers, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s %s, expected a number", name, value)
+			}
+			*target = workers
+		}
+	}
+
+	if value := getenv("BALANCE_TOLERANCE"); value != "" {
+		tolerance, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid BALANCE_TOLERANCE %s, expected a number", value)
+		}
+		cfg.BalanceTolerance = tolerance
+	}
+
+	if value := getenv("HEADER_CASE_SENSITIVE"); value != "" {
+		caseSensitive, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid HEADER_CASE_SENSITIVE %s, expected true or false", value)
+		}
+		cfg.Headers.CaseSensitive = caseSensitive
+	}
+
+	if err := cfg.readBankAmountFormats(getenv("BANK_AMOUNT_FORMATS")); err != nil {
+		return err
+	}
+	if err := cfg.readColumnAliases(getenv("HEADER_ALIASES")); err != nil {
+		return err
+	}
+
+	quality, err := impl.ParseQualityThresholds(getenv)
+	if err != nil {
+		return err
+	}
+	overrideQuality(&cfg.DataQuality.PerFile, quality.PerFile)
+	overrideQuality(&cfg.DataQuality.Global, quality.Global)
+
+	discrepancy, err := impl.ParseDiscrepancyThresholds(getenv)
+	if err != nil {
+		return err
+	}
+	override(&cfg.Discrepancy.MaxAmount, discrepancy.MaxAmount)
+	override(&cfg.Discrepancy.MaxUnmatched, discrepancy.MaxUnmatched)
+	override(&cfg.Discrepancy.MaxUnmatchedPct, discrepancy.MaxUnmatchedPct)
+
+	return nil
+}
+
+// readBankAmountFormats reads a spec such as "bankA:id,bankB:auto", the banks it names replace those of the file
+func (cfg *Config) readBankAmountFormats(spec string) error {
+	if spec == "" {
+		return nil
+	}
+
+	for _, entry := range splitSpec(spec) {
+		bankName, format, found := cut(entry)
+		if !found {
+			return fmt.Errorf("invalid BANK_AMOUNT_FORMATS entry %q, expected bankName:format", entry)
+		}
+		if cfg.Banks == nil {
+			cfg.Banks = make(map[string]BankProfile)
+		}
+		profile := cfg.Banks[bankName]
+		profile.AmountFormat = format
+		cfg.Banks[bankName] = profile
+	}
+	return nil
+}
+
+// readColumnAliases reads a spec such as "trxID:txn_ref|ref_no,date:value_date", the columns it names replace
+// those of the file
+func (cfg *Config) readColumnAliases(spec string) error {
+	if spec == "" {
+		return nil
+	}
+
+	replaced := make(map[string]bool)
+	for _, entry := range splitSpec(spec) {
+		column, aliases, found := cut(entry)
+		if !found {
+			return fmt.Errorf("invalid HEADER_ALIASES entry %q, expected column:alias|alias", entry)
+		}
+		if cfg.Headers.Aliases == nil {
+			cfg.Headers.Aliases = make(map[string][]string)
+		}
+		if !replaced[column] {
+			cfg.Headers.Aliases[column], replaced[column] = nil, true
+		}
+		for _, alias := range strings.Split(aliases, "|") {
+			cfg.Headers.Aliases[column] = append(cfg.Headers.Aliases[column], strings.TrimSpace(alias))
+		}
+	}
+	return nil
+}
+
+// Validate checks every setting, and returns all the problems found
+func (cfg *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !slices.Contains(Strategies, cfg.Strategy) {
+		invalid("invalid strategy %s, expected %s", cfg.Strategy, strings.Join(Strategies, ", "))
+	}
+	if cfg.Workers < 0 {
+		invalid("invalid workers %d, expected 0 or more", cfg.Workers)
+	}
+	if cfg.LoaderWorkers < 0 {
+		invalid("invalid loader_workers %d, expected 0 or more", cfg.LoaderWorkers)
+	}
+
+	for bankName, profile := range cfg.Banks {
+		if _, err := util.GetAmountFormat(profile.AmountFormat); err != nil {
+			invalid("bank %s: %w", bankName, err)
+		}
+	}
+	for column := range cfg.Headers.Aliases {
+		if !validator.IsKnownColumn(column) {
+			invalid("invalid header alias: unknown column %s", column)
+		}
+	}
+
+	for name, file := range map[string]string{"validation_rules_file": cfg.ValidationRulesFile, "bank_balances_file": cfg.BankBalancesFile} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			invalid("invalid %s: %w", name, err)
+		}
+	}
+
+	if cfg.Output.Format != "text" && cfg.Output.Format != "json" {
+		invalid("invalid output format %s, expected text or json", cfg.Output.Format)
+	}
+
+	if _, err := impl.ParseAgingBuckets(cfg.Aging.Buckets); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.Aging.ReferenceDate != "" {
+		if _, err := time.Parse("20060102", cfg.Aging.ReferenceDate); err != nil {
+			invalid("invalid aging reference date %s, expected YYYYMMDD", cfg.Aging.ReferenceDate)
+		}
+	}
+
+	for name, value := range map[string]*float64{
+		"balance_tolerance":                          &cfg.BalanceTolerance,
+		"data_quality.per_file.max_rejected_pct":     cfg.DataQuality.PerFile.MaxRejectedPct,
+		"data_quality.per_file.max_out_of_range_pct": cfg.DataQuality.PerFile.MaxOutOfRangePct,
+		"data_quality.global.max_rejected_pct":       cfg.DataQuality.Global.MaxRejectedPct,
+		"data_quality.global.max_out_of_range_pct":   cfg.DataQuality.Global.MaxOutOfRangePct,
+		"discrepancy.max_amount":                     cfg.Discrepancy.MaxAmount,
+		"discrepancy.max_unmatched_pct":              cfg.Discrepancy.MaxUnmatchedPct,
+	} {
+		if value != nil && *value < 0 {
+			invalid("invalid %s %g, expected a non negative number", name, *value)
+		}
+	}
+	for name, value := range map[string]*int{
+		"data_quality.per_file.min_rows": cfg.DataQuality.PerFile.MinRowCount,
+		"data_quality.global.min_rows":   cfg.DataQuality.Global.MinRowCount,
+		"discrepancy.max_unmatched":      cfg.Discrepancy.MaxUnmatched,
+	} {
+		if value != nil && *value < 0 {
+			invalid("invalid %s %d, expected a non negative integer", name, *value)
+		}
+	}
+
+	// Settings are checked from maps, the problems are sorted so they are always listed in the same order
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
+}
+
+// Write prints the settings as a config file
+func (cfg *Config) Write(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return encoder.Close()
+}
+
+func overrideQuality(target *model.QualityThresholds, source model.QualityThresholds) {
+	override(&target.MaxRejectedPct, source.MaxRejectedPct)
+	override(&target.MinRowCount, source.MinRowCount)
+	override(&target.MaxOutOfRangePct, source.MaxOutOfRangePct)
+}
+
+// override replaces a threshold when the source sets it
+func override[T any](target **T, source *T) {
+	if source != nil {
+		*target = source
+	}
+}
+
+// splitSpec splits a spec such as "bankA:id,bankB:auto" in its entries
+func splitSpec(spec string) []string {
+	var entries []string
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// cut splits a "name:value" entry of a spec, both must be set
+func cut(entry string) (string, string, bool) {
+	name, value, found := strings.Cut(entry, ":")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	return name, value, found && name != "" && value != ""
+}
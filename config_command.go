@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sientong/reconciliation-service/cli"
+)
+
+// runConfig prints the effective settings and checks them, it returns the exit status
+func runConfig(args []string) int {
+	flags := cli.NewFlagSet("config", "",
+		"Prints the effective settings, made of the defaults, the config file and the environment, and checks them.\n"+
+			"The flags of the other commands are applied over these settings.")
+	var settings settingsFlags
+	settings.register(flags)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	cfg, err := settings.load(flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	if cfg.File != "" {
+		fmt.Printf("# Effective settings, config file %s\n", cfg.File)
+	} else {
+		fmt.Println("# Effective settings, without config file")
+	}
+	if err := cfg.Write(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInternalError
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid settings:\n%v\n", err)
+		return exitInputFailure
+	}
+	return exitSuccess
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sientong/reconciliation-service/cli"
+	impl "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/report"
+)
+
+// Exit statuses of the diff command
+const (
+	exitNoChanges = 0
+	exitChanges   = 1
+)
+
+// runDiff compares two results written with --format json and returns the exit status
+func runDiff(args []string) int {
+	flags := cli.NewFlagSet("diff", "previous.json current.json",
+		"Compares two results stored with 'reconcile --format json'. Exits with 0 without changes and 1 with changes.")
+	format := flags.String("format", "text", "output `format` of the changes, text or json")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %s, expected text or json\n", *format)
+		return exitInputFailure
+	}
+
+	if flags.NArg() != 2 {
+		fmt.Fprintf(os.Stderr, "Error: expected the previous and the current result files, got %d arguments\n", flags.NArg())
+		flags.Usage()
+		return exitInputFailure
+	}
+
+	previous, err := report.ReadJSON(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	current, err := report.ReadJSON(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	diff := impl.DiffResults(previous, current)
+	diff.Previous, diff.Current = flags.Arg(0), flags.Arg(1)
+
+	if *format == "json" {
+		if err := report.WriteDiffJSON(os.Stdout, diff); err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon writing changes:", err)
+			return exitInternalError
+		}
+	} else {
+		report.WriteDiff(os.Stdout, diff)
+	}
+
+	if diff.Empty() {
+		return exitNoChanges
+	}
+	return exitChanges
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sientong/reconciliation-service/cli"
+	"github.com/sientong/reconciliation-service/generator"
+	"github.com/sientong/reconciliation-service/report"
+)
+
+// expectedFileName is the results document the generated files are expected to reconcile to
+const expectedFileName = "expected.json"
+
+// runGenerate writes a synthetic dataset and its expected results, it returns the exit status
+func runGenerate(args []string) int {
+	flags := cli.NewFlagSet("generate", "",
+		"Writes a synthetic system transaction file, bank statement files and the results they are expected\n"+
+			"to reconcile to, for load tests and to check the reconciliation. The same flags and seed always\n"+
+			"write the same files.")
+	outputDir := flags.String("output-dir", "", "`directory` receiving the generated files")
+	transactions := flags.Int("transactions", 1000, "`number` of system transactions")
+	banks := flags.Int("banks", 3, "`number` of banks, named bankA, bankB and so on")
+	start := flags.String("start", time.Now().UTC().AddDate(0, 0, -1).Format("20060102"), "first `date` of the transactions, YYYYMMDD, yesterday by default")
+	days := flags.Int("days", 1, "`number` of days of transactions")
+	matchRate := flags.Float64("match-rate", 0.9, "share of the transactions settled in a bank file")
+	lags := flags.String("lag", "0:80,1:15,2:5", "settlement `lags` in days and their weight")
+	feeRate := flags.Float64("fee-rate", 0.05, "share of the settlements with a fee deducted")
+	splitRate := flags.Float64("split-rate", 0.05, "share of the settlements split in several bank rows")
+	duplicateRate := flags.Float64("duplicate-rate", 0.01, "share of the rows written twice")
+	malformedRate := flags.Float64("malformed-rate", 0.01, "share of the rows followed by a malformed row")
+	bankOnlyRate := flags.Float64("bank-only-rate", 0.02, "bank rows without a system transaction, as a share of the transactions")
+	seed := flags.Uint64("seed", 1, "`seed` of the random generator")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	if *outputDir == "" {
+		fmt.Fprintln(os.Stderr, "Error: missing --output-dir")
+		flags.Usage()
+		return exitInputFailure
+	}
+
+	parsedLags, err := generator.ParseLags(*lags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	settings := generator.Settings{
+		Transactions:  *transactions,
+		Banks:         *banks,
+		StartDate:     *start,
+		Days:          *days,
+		MatchRate:     *matchRate,
+		Lags:          parsedLags,
+		FeeRate:       *feeRate,
+		SplitRate:     *splitRate,
+		DuplicateRate: *duplicateRate,
+		MalformedRate: *malformedRate,
+		BankOnlyRate:  *bankOnlyRate,
+		Seed:          *seed,
+	}
+	if err := settings.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	dataset, err := generator.Generate(*outputDir, settings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error upon generating the dataset:", err)
+		return exitInternalError
+	}
+
+	expectedFile := filepath.Join(*outputDir, expectedFileName)
+	out, err := os.Create(expectedFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error upon writing expected results:", err)
+		return exitInternalError
+	}
+	if err := report.WriteJSON(out, dataset.Expected); err != nil {
+		out.Close()
+		fmt.Fprintln(os.Stderr, "Error upon writing expected results:", err)
+		return exitInternalError
+	}
+	if err := out.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error upon writing expected results:", err)
+		return exitInternalError
+	}
+
+	fmt.Println("System transactions written to", dataset.SystemFile)
+	fmt.Printf("%d bank statement files written to %s\n", len(dataset.BankFiles), *outputDir)
+	fmt.Println("Settlements written to", dataset.SettlementsFile)
+	fmt.Println("Expected results written to", expectedFile)
+	fmt.Printf("\nExpected: %d matched, %d unmatched system transactions, %d unmatched bank statements, %d rejected rows\n",
+		dataset.Expected.TotalMatchedTransactions, dataset.Expected.TotalUnmatchedSystemTransactions,
+		dataset.Expected.TotalUnmatchedBankStmts, len(dataset.Expected.RejectedRecords))
+	fmt.Printf("\nReconcile them on %s to %s, e.g.:\n", dataset.StartDate, dataset.EndDate)
+	fmt.Printf("  %s reconcile --format json --system %s --bank %s --start %s --end %s > results.json\n",
+		cli.ProgramName, dataset.SystemFile, strings.Join(dataset.BankFiles, ","), dataset.StartDate, dataset.EndDate)
+	fmt.Printf("  %s diff %s results.json\n", cli.ProgramName, expectedFile)
+
+	return exitSuccess
+}
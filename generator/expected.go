@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// expectedOutput works out the results of reconciling the generated files: a system transaction matches a
+// bank row of the same amount, day and direction. Amounts are unique per settlement, so which of two
+// identical rows is matched does not change the results.
+func expectedOutput(files []*file) *model.Output {
+	output := &model.Output{UnmatchedBankStmts: make(map[string][]model.BankStatementRecord)}
+
+	type bankEntry struct {
+		bankName string
+		record   model.BankStatementRecord
+	}
+	available := make(map[string][]bankEntry)
+	var systemRecords []model.InternalTransactionRecord
+	discrepancyCents := int64(0)
+
+	for _, f := range files {
+		for _, r := range f.rows {
+			switch {
+			case r.rejected != nil:
+				output.RejectedRecords = append(output.RejectedRecords, *r.rejected)
+			case r.system != nil:
+				systemRecords = append(systemRecords, *r.system)
+			case r.bank != nil:
+				key := matchKey(r.bank.Amount, r.bank.Date, r.bank.Direction)
+				available[key] = append(available[key], bankEntry{f.bankName, *r.bank})
+			}
+		}
+	}
+
+	for _, trx := range systemRecords {
+		key := matchKey(trx.Amount, trx.TransactionTime[:10], trx.Type)
+		if len(available[key]) == 0 {
+			output.UnmatchedSystemTransactions = append(output.UnmatchedSystemTransactions, trx)
+			discrepancyCents += cents(trx.Amount)
+			continue
+		}
+
+		entry := available[key][0]
+		available[key] = available[key][1:]
+
+		trx.IsMatched, entry.record.IsMatched = true, true
+		output.MatchedPairs = append(output.MatchedPairs, model.MatchedPair{
+			System:   trx,
+			BankName: entry.bankName,
+			Bank:     entry.record,
+			Rule:     model.MatchRuleExact,
+		})
+	}
+
+	for _, entries := range available {
+		for _, entry := range entries {
+			output.UnmatchedBankStmts[entry.bankName] = append(output.UnmatchedBankStmts[entry.bankName], entry.record)
+			output.TotalUnmatchedBankStmts++
+			discrepancyCents += cents(entry.record.Amount)
+		}
+	}
+
+	sort.Slice(output.MatchedPairs, func(i, j int) bool { return output.MatchedPairs[i].System.TrxID < output.MatchedPairs[j].System.TrxID })
+	sort.Slice(output.UnmatchedSystemTransactions, func(i, j int) bool {
+		return output.UnmatchedSystemTransactions[i].TrxID < output.UnmatchedSystemTransactions[j].TrxID
+	})
+	for _, stmts := range output.UnmatchedBankStmts {
+		sort.Slice(stmts, func(i, j int) bool { return stmts[i].UniqueIdentifier < stmts[j].UniqueIdentifier })
+	}
+
+	output.TotalMatchedTransactions = len(output.MatchedPairs)
+	output.TotalUnmatchedSystemTransactions = len(output.UnmatchedSystemTransactions)
+	output.TotalUnmatchedTransactions = output.TotalUnmatchedSystemTransactions + output.TotalUnmatchedBankStmts
+	output.TotalProcessedRecords = len(systemRecords) + output.TotalUnmatchedBankStmts
+	output.TotalInvalidRecords = len(output.RejectedRecords)
+	output.TotalDiscrepancies = float64(discrepancyCents) / 100
+
+	output.Outcome = model.OutcomeReconciled
+	if output.TotalUnmatchedTransactions > 0 {
+		output.Outcome = model.OutcomeUnmatched
+	}
+
+	return output
+}
+
+func matchKey(amount float64, day string, direction string) string {
+	return strconv.FormatInt(cents(amount), 10) + "|" + day + "|" + direction
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(math.Abs(amount) * 100))
+}
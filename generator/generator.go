@@ -0,0 +1,566 @@
+package generator
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// Scenarios of a generated settlement, they tell how a system transaction reached the bank files
+const (
+	// ScenarioExact is settled on the same day for the same amount, it is expected to match
+	ScenarioExact = "exact"
+	// ScenarioLagged is settled for the same amount days after the transaction
+	ScenarioLagged = "lagged"
+	// ScenarioFee is settled with a fee deducted from the amount
+	ScenarioFee = "fee"
+	// ScenarioSplit is settled in several bank rows which add up to the amount
+	ScenarioSplit = "split"
+	// ScenarioUnsettled is a system transaction without any bank row
+	ScenarioUnsettled = "unsettled"
+	// ScenarioBankOnly is a bank row without any system transaction, such as a bank charge
+	ScenarioBankOnly = "bank_only"
+)
+
+// SystemFileName is the name of the generated system transaction file
+const SystemFileName = "system_transactions.csv"
+
+// SettlementsFileName is the name of the file telling the scenario of every generated settlement
+const SettlementsFileName = "settlements.csv"
+
+// Amounts are drawn between 100.00 and 10,000,000.00, fees up to 50.00
+const (
+	minAmountCents = 10000
+	maxAmountCents = 1000000000
+	maxFeeCents    = 5000
+)
+
+// Lag is the weight of a settlement lag in days
+type Lag struct {
+	Days   int
+	Weight float64
+}
+
+// Settings describe the dataset to generate. Rates are shares between 0 and 1.
+type Settings struct {
+	Transactions int
+	Banks        int
+	// StartDate is the first day of the system transactions, YYYYMMDD
+	StartDate string
+	Days      int
+	// MatchRate is the share of system transactions settled in a bank file, whatever the scenario
+	MatchRate float64
+	Lags      []Lag
+	// FeeRate and SplitRate are shares of the settled transactions
+	FeeRate   float64
+	SplitRate float64
+	// DuplicateRate is the share of rows written twice in their file
+	DuplicateRate float64
+	// MalformedRate is the share of rows followed by a row which is rejected when loading
+	MalformedRate float64
+	// BankOnlyRate is the number of bank rows without a system transaction, as a share of the transactions
+	BankOnlyRate float64
+	Seed         uint64
+}
+
+// Settlement is the truth about a generated system transaction or bank only row
+type Settlement struct {
+	TrxID       string
+	Scenario    string
+	BankName    string
+	Identifiers []string
+	LagDays     int
+	Fee         float64
+	Amount      float64
+}
+
+// Dataset is what was generated: the input files, the date range to reconcile them on and the expected results
+type Dataset struct {
+	SystemFile      string
+	BankFiles       []string
+	SettlementsFile string
+	StartDate       string
+	EndDate         string
+	Settlements     []Settlement
+	// Expected are the results of reconciling the files with the exact amount, date and direction rule
+	Expected *model.Output
+}
+
+// ParseLags reads a settlement lag distribution such as "0:80,1:15,2:5", days and their weight
+func ParseLags(spec string) ([]Lag, error) {
+	var lags []Lag
+	total := 0.0
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		days, weight, found := strings.Cut(entry, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid lag %q, expected days:weight", entry)
+		}
+
+		lag := Lag{}
+		var err error
+		if lag.Days, err = strconv.Atoi(strings.TrimSpace(days)); err != nil || lag.Days < 0 {
+			return nil, fmt.Errorf("invalid lag %q, expected a positive number of days", entry)
+		}
+		if lag.Weight, err = strconv.ParseFloat(strings.TrimSpace(weight), 64); err != nil || lag.Weight < 0 {
+			return nil, fmt.Errorf("invalid lag %q, expected a positive weight", entry)
+		}
+
+		total += lag.Weight
+		lags = append(lags, lag)
+	}
+
+	if total == 0 {
+		return nil, fmt.Errorf("invalid lags %q, the weights add up to 0", spec)
+	}
+
+	return lags, nil
+}
+
+// Validate checks the settings before generating anything
+func (s Settings) Validate() error {
+	if s.Transactions < 1 {
+		return fmt.Errorf("invalid transactions %d, expected at least 1", s.Transactions)
+	}
+	if s.Banks < 1 || s.Banks > 26 {
+		return fmt.Errorf("invalid banks %d, expected 1 to 26", s.Banks)
+	}
+	if s.Days < 1 {
+		return fmt.Errorf("invalid days %d, expected at least 1", s.Days)
+	}
+	if _, err := time.Parse("20060102", s.StartDate); err != nil {
+		return fmt.Errorf("invalid start date %s, expected YYYYMMDD", s.StartDate)
+	}
+	if len(s.Lags) == 0 {
+		return fmt.Errorf("missing settlement lags")
+	}
+
+	rates := []struct {
+		name  string
+		value float64
+	}{
+		{"match rate", s.MatchRate},
+		{"fee rate", s.FeeRate},
+		{"split rate", s.SplitRate},
+		{"duplicate rate", s.DuplicateRate},
+		{"malformed rate", s.MalformedRate},
+		{"bank only rate", s.BankOnlyRate},
+	}
+	for _, rate := range rates {
+		if rate.value < 0 || rate.value > 1 {
+			return fmt.Errorf("invalid %s %g, expected 0 to 1", rate.name, rate.value)
+		}
+	}
+	if s.FeeRate+s.SplitRate > 1 {
+		return fmt.Errorf("invalid fee rate %g and split rate %g, they add up to more than 1", s.FeeRate, s.SplitRate)
+	}
+
+	return nil
+}
+
+// row is a line of a generated file, with the record it is loaded as or the reason it is rejected for
+type row struct {
+	fields   []string
+	system   *model.InternalTransactionRecord
+	bank     *model.BankStatementRecord
+	rejected *model.RejectedRecord
+}
+
+// file is a generated csv file before its rows are shuffled and written
+type file struct {
+	path     string
+	header   []string
+	bankName string
+	rows     []row
+}
+
+type generator struct {
+	settings Settings
+	rng      *rand.Rand
+	start    time.Time
+	// used are the amounts in cents already written, so that only the expected rows share an amount
+	used      map[int64]bool
+	system    *file
+	bankFiles map[string]*file
+	bankSeq   map[string]int
+	malformed int
+	dataset   *Dataset
+	// lastDate is the last day of the transactions and settlements
+	lastDate   time.Time
+	lagWeights float64
+}
+
+// Generate writes a system transaction file, bank statement files named bankName_YYYYMMDD.csv, one per bank
+// and settlement day, and the settlements file into dir. The same settings always generate the same files.
+func Generate(dir string, settings Settings) (*Dataset, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+
+	start, _ := time.Parse("20060102", settings.StartDate)
+	g := &generator{
+		settings:  settings,
+		rng:       rand.New(rand.NewPCG(settings.Seed, settings.Seed)),
+		start:     start,
+		used:      make(map[int64]bool),
+		system:    &file{path: filepath.Join(dir, SystemFileName), header: []string{"trxID", "amount", "type", "transactionTime"}},
+		bankFiles: make(map[string]*file),
+		bankSeq:   make(map[string]int),
+		dataset:   &Dataset{SettlementsFile: filepath.Join(dir, SettlementsFileName)},
+		lastDate:  start.AddDate(0, 0, settings.Days-1),
+	}
+	for _, lag := range settings.Lags {
+		g.lagWeights += lag.Weight
+	}
+
+	for i := 1; i <= settings.Transactions; i++ {
+		g.transaction(fmt.Sprintf("TX%07d", i))
+	}
+
+	bankOnly := int(settings.BankOnlyRate*float64(settings.Transactions) + 0.5)
+	for range bankOnly {
+		g.bankOnly()
+	}
+
+	files := []*file{g.system}
+	for _, path := range sortedKeys(g.bankFiles) {
+		files = append(files, g.bankFiles[path])
+		g.dataset.BankFiles = append(g.dataset.BankFiles, path)
+	}
+
+	for _, f := range files {
+		g.rng.Shuffle(len(f.rows), func(i, j int) { f.rows[i], f.rows[j] = f.rows[j], f.rows[i] })
+		if err := writeFile(f); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeSettlements(g.dataset.SettlementsFile, g.dataset.Settlements); err != nil {
+		return nil, err
+	}
+
+	g.dataset.SystemFile = g.system.path
+	g.dataset.StartDate = settings.StartDate
+	g.dataset.EndDate = g.lastDate.Format("20060102")
+	g.dataset.Expected = expectedOutput(files)
+
+	return g.dataset, nil
+}
+
+// transaction generates a system transaction and its settlement
+func (g *generator) transaction(trxID string) {
+	day := g.start.AddDate(0, 0, g.rng.IntN(g.settings.Days))
+	transactionTime := day.Add(time.Duration(g.rng.IntN(24*60*60)) * time.Second)
+	credit := g.rng.IntN(2) == 0
+	cents := g.amount()
+
+	transactionType := model.DirectionDebit
+	if credit {
+		transactionType = model.DirectionCredit
+	}
+	fields := []string{trxID, formatCents(cents), strings.ToUpper(transactionType), transactionTime.Format(time.RFC3339)}
+	g.addRow(g.system, row{fields: fields, system: &model.InternalTransactionRecord{
+		TrxID:           trxID,
+		Amount:          float64(cents) / 100,
+		Type:            transactionType,
+		TransactionTime: fields[3],
+	}})
+
+	settlement := Settlement{TrxID: trxID, Scenario: ScenarioUnsettled, Amount: float64(cents) / 100}
+	if g.rng.Float64() >= g.settings.MatchRate {
+		g.dataset.Settlements = append(g.dataset.Settlements, settlement)
+		return
+	}
+
+	settlement.BankName = bankName(g.rng.IntN(g.settings.Banks))
+	settlement.LagDays = g.lag()
+	settlementDay := day.AddDate(0, 0, settlement.LagDays)
+
+	var parts []int64
+	scenario := g.rng.Float64()
+	switch {
+	case scenario < g.settings.SplitRate:
+		settlement.Scenario = ScenarioSplit
+		parts = g.split(cents)
+	case scenario < g.settings.SplitRate+g.settings.FeeRate:
+		settlement.Scenario = ScenarioFee
+		fee, settled := g.fee(cents, credit)
+		settlement.Fee = float64(fee) / 100
+		parts = []int64{settled}
+	case settlement.LagDays > 0:
+		settlement.Scenario = ScenarioLagged
+		parts = []int64{cents}
+	default:
+		settlement.Scenario = ScenarioExact
+		parts = []int64{cents}
+	}
+
+	for _, part := range parts {
+		settlement.Identifiers = append(settlement.Identifiers, g.bankRow(settlement.BankName, settlementDay, part, credit))
+	}
+	g.dataset.Settlements = append(g.dataset.Settlements, settlement)
+}
+
+// bankOnly generates a bank row without a system transaction
+func (g *generator) bankOnly() {
+	name := bankName(g.rng.IntN(g.settings.Banks))
+	day := g.start.AddDate(0, 0, g.rng.IntN(g.settings.Days))
+	cents := g.amount()
+	credit := g.rng.IntN(2) == 0
+
+	identifier := g.bankRow(name, day, cents, credit)
+	g.dataset.Settlements = append(g.dataset.Settlements, Settlement{
+		Scenario:    ScenarioBankOnly,
+		BankName:    name,
+		Identifiers: []string{identifier},
+		Amount:      float64(cents) / 100,
+	})
+}
+
+// bankRow adds a bank statement row to the file of the bank and day, debits are written as negative amounts
+func (g *generator) bankRow(name string, day time.Time, cents int64, credit bool) string {
+	path := filepath.Join(filepath.Dir(g.system.path), fmt.Sprintf("%s_%s.csv", name, day.Format("20060102")))
+	f := g.bankFiles[path]
+	if f == nil {
+		f = &file{path: path, header: []string{"unique_identifier", "amount", "date"}, bankName: name}
+		g.bankFiles[path] = f
+	}
+	if day.After(g.lastDate) {
+		g.lastDate = day
+	}
+
+	g.bankSeq[name]++
+	identifier := fmt.Sprintf("B%s%07d", strings.TrimPrefix(name, "bank"), g.bankSeq[name])
+
+	direction, signed := model.DirectionCredit, cents
+	if !credit {
+		direction, signed = model.DirectionDebit, -cents
+	}
+	fields := []string{identifier, formatCents(signed), day.Format("2006-01-02")}
+	g.addRow(f, row{fields: fields, bank: &model.BankStatementRecord{
+		UniqueIdentifier: identifier,
+		Amount:           float64(signed) / 100,
+		Direction:        direction,
+		Date:             fields[2],
+	}})
+
+	return identifier
+}
+
+// addRow adds a row to a file, sometimes twice, and sometimes followed by a malformed row
+func (g *generator) addRow(f *file, r row) {
+	f.rows = append(f.rows, r)
+	if g.rng.Float64() < g.settings.DuplicateRate {
+		f.rows = append(f.rows, r)
+	}
+	if g.rng.Float64() < g.settings.MalformedRate {
+		f.rows = append(f.rows, g.malformedRow(f, r))
+	}
+}
+
+// malformedRow spoils a copy of a valid row so that it is rejected for a known reason
+func (g *generator) malformedRow(f *file, valid row) row {
+	g.malformed++
+	fields := append([]string(nil), valid.fields...)
+	fields[0] = fmt.Sprintf("BAD%07d", g.malformed)
+
+	reasons := []string{model.ReasonBadAmount, model.ReasonBadDate, model.ReasonWrongColumnCount}
+	if valid.system != nil {
+		reasons = append(reasons, model.ReasonBadType)
+	}
+
+	rejected := &model.RejectedRecord{Reason: reasons[g.rng.IntN(len(reasons))]}
+	switch rejected.Reason {
+	case model.ReasonBadAmount:
+		rejected.Field = "amount"
+		fields[1] = "n/a"
+	case model.ReasonBadDate:
+		rejected.Field = f.header[len(f.header)-1]
+		fields[len(fields)-1] = "31/12/2024"
+	case model.ReasonBadType:
+		rejected.Field = "type"
+		fields[2] = "TRANSFER"
+	case model.ReasonWrongColumnCount:
+		fields = fields[:len(fields)-1]
+	}
+	rejected.Message = "generated " + rejected.Reason + " row"
+	rejected.Raw = rawRow(fields)
+
+	return row{fields: fields, rejected: rejected}
+}
+
+// amount draws an amount which was not used yet
+func (g *generator) amount() int64 {
+	for {
+		cents := minAmountCents + g.rng.Int64N(maxAmountCents-minAmountCents)
+		if g.claim(cents) {
+			return cents
+		}
+	}
+}
+
+// claim reserves an amount, it returns false when the amount was already used
+func (g *generator) claim(cents int64) bool {
+	if g.used[cents] {
+		return false
+	}
+	g.used[cents] = true
+	return true
+}
+
+// fee draws a fee and the settled amount: credits arrive with the fee deducted, debits leave with the fee added
+func (g *generator) fee(cents int64, credit bool) (int64, int64) {
+	for {
+		fee := 1 + g.rng.Int64N(min(maxFeeCents, cents/10))
+		settled := cents + fee
+		if credit {
+			settled = cents - fee
+		}
+		if g.claim(settled) {
+			return fee, settled
+		}
+	}
+}
+
+// split cuts an amount in two or three parts which were not used yet
+func (g *generator) split(cents int64) []int64 {
+	for {
+		count := 2 + g.rng.IntN(2)
+		parts := make([]int64, 0, count)
+		remaining := cents
+		for i := 1; i < count; i++ {
+			part := 1 + g.rng.Int64N(remaining-int64(count-i))
+			parts = append(parts, part)
+			remaining -= part
+		}
+		parts = append(parts, remaining)
+
+		if g.claimAll(parts) {
+			return parts
+		}
+	}
+}
+
+func (g *generator) claimAll(parts []int64) bool {
+	seen := make(map[int64]bool, len(parts))
+	for _, part := range parts {
+		if g.used[part] || seen[part] {
+			return false
+		}
+		seen[part] = true
+	}
+	for _, part := range parts {
+		g.used[part] = true
+	}
+	return true
+}
+
+// lag draws a settlement lag from the distribution
+func (g *generator) lag() int {
+	draw := g.rng.Float64() * g.lagWeights
+	for _, lag := range g.settings.Lags {
+		if draw < lag.Weight {
+			return lag.Days
+		}
+		draw -= lag.Weight
+	}
+	return g.settings.Lags[len(g.settings.Lags)-1].Days
+}
+
+// bankName is bankA for the first bank, bankB for the second and so on
+func bankName(i int) string {
+	return "bank" + string(rune('A'+i))
+}
+
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// rawRow writes the row as a csv line, the way rejected rows are reported
+func rawRow(fields []string) string {
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	writer.Write(fields)
+	writer.Flush()
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// writeFile writes the header and the rows of a file, and tells the rejected rows their file and line
+func writeFile(f *file) error {
+	out, err := os.Create(f.path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", f.path, err)
+	}
+	defer out.Close()
+
+	writer := csv.NewWriter(out)
+	writer.Write(f.header)
+	for i := range f.rows {
+		writer.Write(f.rows[i].fields)
+		if rejected := f.rows[i].rejected; rejected != nil {
+			copied := *rejected
+			copied.SourceFile, copied.Line = f.path, i+2
+			f.rows[i].rejected = &copied
+		}
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("write %s: %w", f.path, err)
+	}
+	return out.Close()
+}
+
+func writeSettlements(path string, settlements []Settlement) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	defer out.Close()
+
+	writer := csv.NewWriter(out)
+	writer.Write([]string{"trx_id", "scenario", "bank_name", "unique_identifiers", "lag_days", "fee", "amount"})
+	for _, settlement := range settlements {
+		writer.Write([]string{
+			settlement.TrxID,
+			settlement.Scenario,
+			settlement.BankName,
+			strings.Join(settlement.Identifiers, " "),
+			strconv.Itoa(settlement.LagDays),
+			strconv.FormatFloat(settlement.Fee, 'f', 2, 64),
+			strconv.FormatFloat(settlement.Amount, 'f', 2, 64),
+		})
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return out.Close()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,5 +2,9 @@ module github.com/sientong/reconciliation-service
 
 go 1.23.4
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/joho/godotenv v1.5.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
 replace github.com/sientong/reconciliation-service => .
@@ -0,0 +1,131 @@
+package impl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// DefaultAgingBuckets are used when AGING_BUCKETS is not set
+const DefaultAgingBuckets = "0-1,2-3,4-7,8-30,>30"
+
+// AgingBuckets are the age ranges the unmatched items are grouped in
+var AgingBuckets, _ = ParseAgingBuckets(DefaultAgingBuckets)
+
+// ParseAgingBuckets reads buckets such as "0-1,2-3,4-7,8-30,>30". The buckets must follow each other
+// from 0 days without gaps, and the last one must be open ended.
+func ParseAgingBuckets(spec string) ([]model.AgingBucket, error) {
+	var buckets []model.AgingBucket
+	next := 0
+
+	for _, entry := range strings.Split(spec, ",") {
+		label := strings.TrimSpace(entry)
+		if len(buckets) > 0 && buckets[len(buckets)-1].MaxDays < 0 {
+			return nil, fmt.Errorf("invalid aging bucket %q: the open ended bucket must be the last one", label)
+		}
+
+		bucket := model.AgingBucket{Label: label}
+		if after, open := strings.CutPrefix(label, ">"); open {
+			days, err := strconv.Atoi(strings.TrimSpace(after))
+			if err != nil {
+				return nil, fmt.Errorf("invalid aging bucket %q, expected min-max or >days", label)
+			}
+			bucket.MinDays, bucket.MaxDays = days+1, -1
+		} else {
+			bounds := strings.SplitN(label, "-", 2)
+			if len(bounds) != 2 {
+				return nil, fmt.Errorf("invalid aging bucket %q, expected min-max or >days", label)
+			}
+			minDays, minErr := strconv.Atoi(strings.TrimSpace(bounds[0]))
+			maxDays, maxErr := strconv.Atoi(strings.TrimSpace(bounds[1]))
+			if minErr != nil || maxErr != nil || maxDays < minDays {
+				return nil, fmt.Errorf("invalid aging bucket %q, expected min-max or >days", label)
+			}
+			bucket.MinDays, bucket.MaxDays = minDays, maxDays
+		}
+
+		if bucket.MinDays != next {
+			return nil, fmt.Errorf("invalid aging bucket %q: expected it to start at %d days", label, next)
+		}
+		next = bucket.MaxDays + 1
+		buckets = append(buckets, bucket)
+	}
+
+	if buckets[len(buckets)-1].MaxDays >= 0 {
+		return nil, fmt.Errorf("invalid aging buckets %q: the last bucket must be open ended, such as >%d", spec, next-1)
+	}
+
+	return buckets, nil
+}
+
+// AgeUnmatched gives every unmatched item of the output its age in days on the reference date (YYYYMMDD),
+// and sums them per bucket and per bank. Items dated after the reference date are 0 days old, in the first bucket.
+func AgeUnmatched(output *model.Output, referenceDate string) error {
+	reference, err := time.Parse("20060102", referenceDate)
+	if err != nil {
+		return fmt.Errorf("invalid aging reference date %s, expected YYYYMMDD", referenceDate)
+	}
+
+	aging := model.Aging{ReferenceDate: reference.Format("2006-01-02")}
+	for _, bucket := range AgingBuckets {
+		aging.Buckets = append(aging.Buckets, model.AgingBucketSummary{AgingBucket: bucket, ByBank: make(map[string]*model.AgingTotals)})
+	}
+
+	add := func(item model.AgedItem) {
+		if date, err := time.Parse("2006-01-02", item.Date); err == nil {
+			item.AgeDays = max(int(reference.Sub(date).Hours()/24), 0)
+		}
+
+		summary := &aging.Buckets[agingBucket(aging.Buckets, item.AgeDays)]
+		item.Bucket = summary.Label
+		aging.Items = append(aging.Items, item)
+
+		amount := math.Abs(item.Amount)
+		summary.Count++
+		summary.Amount += amount
+
+		totals := &summary.System
+		if item.Side == model.SideBank {
+			if summary.ByBank[item.BankName] == nil {
+				summary.ByBank[item.BankName] = &model.AgingTotals{}
+			}
+			totals = summary.ByBank[item.BankName]
+		}
+		totals.Count++
+		totals.Amount += amount
+	}
+
+	for i := range output.UnmatchedSystemTransactions {
+		trx := &output.UnmatchedSystemTransactions[i]
+		add(model.AgedItem{Side: model.SideSystem, Identifier: trx.TrxID, Amount: trx.Amount, Date: systemTransactionDay(trx)})
+	}
+
+	bankNames := make([]string, 0, len(output.UnmatchedBankStmts))
+	for bankName := range output.UnmatchedBankStmts {
+		bankNames = append(bankNames, bankName)
+	}
+	sort.Strings(bankNames)
+
+	for _, bankName := range bankNames {
+		for _, stmt := range output.UnmatchedBankStmts[bankName] {
+			add(model.AgedItem{Side: model.SideBank, BankName: bankName, Identifier: stmt.UniqueIdentifier, Amount: stmt.Amount, Date: stmt.Date})
+		}
+	}
+
+	output.Aging = aging
+	return nil
+}
+
+func agingBucket(buckets []model.AgingBucketSummary, ageDays int) int {
+	for i, bucket := range buckets {
+		if ageDays <= bucket.MaxDays || bucket.MaxDays < 0 {
+			return i
+		}
+	}
+	return len(buckets) - 1
+}
@@ -0,0 +1,162 @@
+package impl
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/util"
+	"github.com/sientong/reconciliation-service/validator"
+)
+
+// DefaultBalanceTolerance is the balance tolerance used when none is set, half a cent
+const DefaultBalanceTolerance = 0.005
+
+// BalanceTolerance is the largest difference accepted between the expected and the supplied closing balance
+var BalanceTolerance = DefaultBalanceTolerance
+
+var bankBalancesHeader = []string{"file", "opening_balance", "closing_balance"}
+
+// LoadBankBalances reads a side file with the columns file,opening_balance,closing_balance,
+// where file is the name of the bank statement file, e.g. bankA_20250605.csv. The balances are
+// written in the amount format of the bank, as in its statements.
+func LoadBankBalances(filePath string) error {
+	file, err := util.OpenCSV(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rows, err := file.ReadAll()
+	if err != nil {
+		return fmt.Errorf("read %s: %w", filePath, err)
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	for i, col := range bankBalancesHeader {
+		if len(rows[0]) != len(bankBalancesHeader) || strings.TrimSpace(rows[0][i]) != col {
+			return fmt.Errorf("invalid header in %s: expected %s", filePath, strings.Join(bankBalancesHeader, ","))
+		}
+	}
+
+	for i, row := range rows[1:] {
+		balance := model.StatementBalance{}
+		format := bankAmountFormat(bankNameFromPath(row[0]))
+		for j, target := range []**float64{&balance.Opening, &balance.Closing} {
+			raw := strings.TrimSpace(row[j+1])
+			if raw == "" {
+				continue
+			}
+
+			value, err := util.ParseAmount(raw, format)
+			if err != nil {
+				return fmt.Errorf("%s line %d: invalid %s %s", filePath, i+2, bankBalancesHeader[j+1], raw)
+			}
+			*target = &value
+		}
+
+		model.BankBalances[filepath.Base(row[0])] = balance
+	}
+
+	return nil
+}
+
+func isBalanceRow(identifier string) bool {
+	identifier = strings.ToUpper(strings.TrimSpace(identifier))
+	return identifier == model.OpeningBalanceIdentifier || identifier == model.ClosingBalanceIdentifier
+}
+
+// parseBalanceRow reads the balance of an inline OPENING_BALANCE or CLOSING_BALANCE row, the date is not checked
+func parseBalanceRow(record []string, header validator.Header, format model.AmountFormat) (*model.BankStatementRecord, error) {
+	if len(record) != header.Width {
+		return nil, rejectField("", model.ReasonWrongColumnCount, fmt.Errorf("expected %d columns, got %d", header.Width, len(record)))
+	}
+
+	columns := header.Columns
+
+	var balance float64
+	if _, exists := columns["amount"]; exists {
+		amount, _, err := parseBankStatementAmount(record, header, format)
+		if err != nil {
+			return nil, err
+		}
+		balance = amount
+	} else {
+		// Debit and credit columns, an empty balance row is a zero balance
+		for field, sign := range map[string]float64{"credit": 1, "debit": -1} {
+			raw := strings.TrimSpace(record[columns[field]])
+			if raw == "" {
+				continue
+			}
+			amount, err := util.ParseAmount(raw, format)
+			if err != nil {
+				return nil, rejectField(field, model.ReasonBadAmount, fmt.Errorf("parse %s: %w", field, err))
+			}
+			balance += sign * math.Abs(amount)
+		}
+	}
+
+	return &model.BankStatementRecord{
+		UniqueIdentifier: strings.ToUpper(strings.TrimSpace(record[columns["unique_identifier"]])),
+		Amount:           balance,
+		Date:             record[columns["date"]],
+	}, nil
+}
+
+// checkBalance compares the supplied balances with the statement lines, lines outside the date range
+// are part of the statement and are included in the total
+func checkBalance(filePath string, bankName string, inline model.StatementBalance, linesTotal float64, rejectedRows int) model.BalanceCheck {
+	balance := model.BankBalances[filepath.Base(filePath)]
+	if inline.Opening != nil {
+		balance.Opening = inline.Opening
+	}
+	if inline.Closing != nil {
+		balance.Closing = inline.Closing
+	}
+
+	check := model.BalanceCheck{
+		File:       filePath,
+		BankName:   bankName,
+		Opening:    balance.Opening,
+		Closing:    balance.Closing,
+		LinesTotal: linesTotal,
+	}
+
+	switch {
+	case balance.Opening == nil && balance.Closing == nil:
+		check.Status = model.BalanceNotSupplied
+		check.Message = "no opening or closing balance supplied"
+		return check
+	case balance.Opening == nil || balance.Closing == nil:
+		check.Status = model.BalanceIncomplete
+		check.Message = "only one of the opening and closing balance is supplied"
+		return check
+	}
+
+	check.Difference = *balance.Closing - (*balance.Opening + linesTotal)
+	if math.Abs(check.Difference) <= BalanceTolerance {
+		check.Difference = 0
+		check.Status = model.BalanceBalanced
+		check.Message = fmt.Sprintf("opening %.2f + lines %.2f = closing %.2f", *balance.Opening, linesTotal, *balance.Closing)
+		return check
+	}
+
+	check.Status = model.BalanceBreak
+	if check.Difference > 0 {
+		check.Message = fmt.Sprintf("opening %.2f + lines %.2f is %.2f below closing %.2f, lines are missing from the statement",
+			*balance.Opening, linesTotal, check.Difference, *balance.Closing)
+	} else {
+		check.Message = fmt.Sprintf("opening %.2f + lines %.2f is %.2f above closing %.2f, the statement has extra lines",
+			*balance.Opening, linesTotal, -check.Difference, *balance.Closing)
+	}
+	if rejectedRows > 0 {
+		check.Message += fmt.Sprintf(" (%d rejected rows are not part of the lines total)", rejectedRows)
+	}
+
+	return check
+}
@@ -0,0 +1,130 @@
+package impl
+
+import (
+	"math"
+	"time"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// breakdowns returns the statistics of the bank, of the day and of the bank on the day, creating them
+// when needed. An empty bank name is a system transaction which was not matched to any bank.
+func breakdowns(output *model.Output, bankName string, day string) []*model.Breakdown {
+	if output.ByDay == nil {
+		output.ByDay = make(map[string]*model.Breakdown)
+		output.ByBank = make(map[string]*model.Breakdown)
+		output.ByBankDay = make(map[string]map[string]*model.Breakdown)
+	}
+
+	if output.ByDay[day] == nil {
+		output.ByDay[day] = &model.Breakdown{}
+	}
+	if bankName == "" {
+		return []*model.Breakdown{output.ByDay[day]}
+	}
+
+	if output.ByBank[bankName] == nil {
+		output.ByBank[bankName] = &model.Breakdown{}
+		output.ByBankDay[bankName] = make(map[string]*model.Breakdown)
+	}
+	if output.ByBankDay[bankName][day] == nil {
+		output.ByBankDay[bankName][day] = &model.Breakdown{}
+	}
+	return []*model.Breakdown{output.ByDay[day], output.ByBank[bankName], output.ByBankDay[bankName][day]}
+}
+
+// systemTransactionDay is the calendar day of a system transaction, as the bank statements write it
+func systemTransactionDay(transaction *model.InternalTransactionRecord) string {
+	transactionTime, err := time.Parse(time.RFC3339, transaction.TransactionTime)
+	if err != nil {
+		return transaction.TransactionTime
+	}
+	return transactionTime.Format("2006-01-02")
+}
+
+func addSystemSums(breakdown *model.Breakdown, transaction *model.InternalTransactionRecord) {
+	breakdown.Processed++
+	if transaction.Type == model.DirectionCredit {
+		breakdown.SystemCredits += math.Abs(transaction.Amount)
+	} else {
+		breakdown.SystemDebits += math.Abs(transaction.Amount)
+	}
+}
+
+func addBankSums(breakdown *model.Breakdown, bankRecord *model.BankStatementRecord) {
+	breakdown.Processed++
+	if bankRecord.Direction == model.DirectionCredit {
+		breakdown.BankCredits += math.Abs(bankRecord.Amount)
+	} else {
+		breakdown.BankDebits += math.Abs(bankRecord.Amount)
+	}
+}
+
+// countMatch adds a matched pair to the breakdowns. The system transaction is counted on its own day
+// and the bank statement on its own day, the match is counted on the day of the bank statement.
+func countMatch(output *model.Output, transaction *model.InternalTransactionRecord, bankName string, bankRecord *model.BankStatementRecord) {
+	systemDay := systemTransactionDay(transaction)
+	for _, breakdown := range breakdowns(output, bankName, systemDay) {
+		addSystemSums(breakdown, transaction)
+	}
+	for _, breakdown := range breakdowns(output, bankName, bankRecord.Date) {
+		addBankSums(breakdown, bankRecord)
+		breakdown.Matched++
+	}
+}
+
+// recordUnmatchedSystemTransaction adds a system transaction without a bank statement to the output
+func recordUnmatchedSystemTransaction(output *model.Output, transaction *model.InternalTransactionRecord) {
+	output.UnmatchedSystemTransactions = append(output.UnmatchedSystemTransactions, *transaction)
+	output.TotalDiscrepancies += math.Abs(transaction.Amount)
+	output.TotalUnmatchedSystemTransactions++
+	output.TotalUnmatchedTransactions++
+
+	for _, breakdown := range breakdowns(output, "", systemTransactionDay(transaction)) {
+		addSystemSums(breakdown, transaction)
+		breakdown.UnmatchedSystem++
+	}
+}
+
+func countUnmatchedBankStmt(output *model.Output, bankName string, bankRecord *model.BankStatementRecord) {
+	for _, breakdown := range breakdowns(output, bankName, bankRecord.Date) {
+		addBankSums(breakdown, bankRecord)
+		breakdown.UnmatchedBank++
+	}
+}
+
+// mergeBreakdowns adds the breakdowns of a worker to the final output
+func mergeBreakdowns(final *model.Output, local *model.Output) {
+	for day, breakdown := range local.ByDay {
+		breakdowns(final, "", day)[0].Add(breakdown)
+	}
+	for bankName, days := range local.ByBankDay {
+		for day, breakdown := range days {
+			breakdowns(final, bankName, day)[2].Add(breakdown)
+		}
+	}
+	for bankName, breakdown := range local.ByBank {
+		final.ByBank[bankName].Add(breakdown)
+	}
+}
+
+// computeMatchRates sets the share of processed records which were matched, once every record is counted
+func computeMatchRates(output *model.Output) {
+	rate := func(breakdown *model.Breakdown) {
+		if breakdown.Processed > 0 {
+			breakdown.MatchRate = float64(breakdown.Processed-breakdown.UnmatchedSystem-breakdown.UnmatchedBank) / float64(breakdown.Processed)
+		}
+	}
+
+	for _, breakdown := range output.ByDay {
+		rate(breakdown)
+	}
+	for _, breakdown := range output.ByBank {
+		rate(breakdown)
+	}
+	for _, days := range output.ByBankDay {
+		for _, breakdown := range days {
+			rate(breakdown)
+		}
+	}
+}
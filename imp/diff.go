@@ -0,0 +1,150 @@
+package impl
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// DiffResults compares two reconciliation results. Matched pairs are needed to find re-paired items and
+// the pairs of newly matched items, they are missing when the runs wrote them to MATCHED_PAIRS_FILE.
+func DiffResults(previous *model.Output, current *model.Output) model.ResultDiff {
+	diff := model.ResultDiff{}
+
+	previousUnmatched := unmatchedItems(previous)
+	currentUnmatched := unmatchedItems(current)
+	currentPairs := pairsByItem(current)
+
+	for key, item := range previousUnmatched {
+		if _, still := currentUnmatched[key]; still {
+			continue
+		}
+		pair, matched := currentPairs[key]
+		switch {
+		case matched:
+			item.Pair = &pair
+			diff.NewlyMatched = append(diff.NewlyMatched, item)
+		case current.MatchedPairsFile != "":
+			// Without the pairs, an item which is no longer unmatched is taken as matched
+			diff.NewlyMatched = append(diff.NewlyMatched, item)
+		default:
+			diff.Removed = append(diff.Removed, item)
+		}
+	}
+
+	for key, item := range currentUnmatched {
+		if _, before := previousUnmatched[key]; !before {
+			diff.NewlyUnmatched = append(diff.NewlyUnmatched, item)
+		}
+	}
+
+	sortDiffItems(diff.NewlyMatched)
+	sortDiffItems(diff.NewlyUnmatched)
+	sortDiffItems(diff.Removed)
+
+	currentByTrx := make(map[string]model.MatchedPair, len(current.MatchedPairs))
+	for _, pair := range current.MatchedPairs {
+		currentByTrx[pair.System.TrxID] = pair
+	}
+	for _, pair := range previous.MatchedPairs {
+		now, matched := currentByTrx[pair.System.TrxID]
+		if matched && (now.BankName != pair.BankName || now.Bank.UniqueIdentifier != pair.Bank.UniqueIdentifier) {
+			diff.Repaired = append(diff.Repaired, model.RepairedItem{TrxID: pair.System.TrxID, Previous: pair, Current: now})
+		}
+	}
+	sort.Slice(diff.Repaired, func(i, j int) bool { return diff.Repaired[i].TrxID < diff.Repaired[j].TrxID })
+
+	diff.RejectedAdded = rejectedDifference(current.RejectedRecords, previous.RejectedRecords)
+	diff.RejectedRemoved = rejectedDifference(previous.RejectedRecords, current.RejectedRecords)
+
+	totals := []struct {
+		name              string
+		previous, current float64
+	}{
+		{"total_processed_records", float64(previous.TotalProcessedRecords), float64(current.TotalProcessedRecords)},
+		{"total_matched_transactions", float64(previous.TotalMatchedTransactions), float64(current.TotalMatchedTransactions)},
+		{"total_unmatched_transactions", float64(previous.TotalUnmatchedTransactions), float64(current.TotalUnmatchedTransactions)},
+		{"total_unmatched_system_transactions", float64(previous.TotalUnmatchedSystemTransactions), float64(current.TotalUnmatchedSystemTransactions)},
+		{"total_unmatched_bank_stmts", float64(previous.TotalUnmatchedBankStmts), float64(current.TotalUnmatchedBankStmts)},
+		{"total_invalid_records", float64(previous.TotalInvalidRecords), float64(current.TotalInvalidRecords)},
+		{"total_discrepancies", previous.TotalDiscrepancies, current.TotalDiscrepancies},
+	}
+	for _, total := range totals {
+		// Amounts differing by less than a cent only come from the order they were added in
+		if math.Abs(total.current-total.previous) < 0.005 {
+			continue
+		}
+		diff.Totals = append(diff.Totals, model.TotalChange{Name: total.name, Previous: total.previous, Current: total.current, Delta: total.current - total.previous})
+	}
+
+	return diff
+}
+
+func systemItemKey(trxID string) string {
+	return model.SideSystem + "|" + trxID
+}
+
+func bankItemKey(bankName string, uniqueIdentifier string) string {
+	return model.SideBank + "|" + bankName + "|" + uniqueIdentifier
+}
+
+func unmatchedItems(output *model.Output) map[string]model.DiffItem {
+	items := make(map[string]model.DiffItem, output.TotalUnmatchedTransactions)
+
+	for i := range output.UnmatchedSystemTransactions {
+		trx := &output.UnmatchedSystemTransactions[i]
+		items[systemItemKey(trx.TrxID)] = model.DiffItem{Side: model.SideSystem, Identifier: trx.TrxID, Amount: trx.Amount, Date: systemTransactionDay(trx)}
+	}
+
+	for bankName, stmts := range output.UnmatchedBankStmts {
+		for _, stmt := range stmts {
+			items[bankItemKey(bankName, stmt.UniqueIdentifier)] = model.DiffItem{Side: model.SideBank, BankName: bankName, Identifier: stmt.UniqueIdentifier, Amount: stmt.Amount, Date: stmt.Date}
+		}
+	}
+
+	return items
+}
+
+// pairsByItem finds the matched pair of a system transaction or a bank statement
+func pairsByItem(output *model.Output) map[string]model.MatchedPair {
+	pairs := make(map[string]model.MatchedPair, 2*len(output.MatchedPairs))
+	for _, pair := range output.MatchedPairs {
+		pairs[systemItemKey(pair.System.TrxID)] = pair
+		pairs[bankItemKey(pair.BankName, pair.Bank.UniqueIdentifier)] = pair
+	}
+	return pairs
+}
+
+func sortDiffItems(items []model.DiffItem) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Side != items[j].Side {
+			// System transactions first
+			return items[i].Side == model.SideSystem
+		}
+		if items[i].BankName != items[j].BankName {
+			return items[i].BankName < items[j].BankName
+		}
+		return items[i].Identifier < items[j].Identifier
+	})
+}
+
+// rejectedDifference returns the rejected rows of records which are not in others. Rows are compared by
+// content and reason, so a resent file under another name does not change them.
+func rejectedDifference(records []model.RejectedRecord, others []model.RejectedRecord) []model.RejectedRecord {
+	seen := make(map[string]int, len(others))
+	for _, other := range others {
+		seen[other.Reason+"|"+other.Raw]++
+	}
+
+	var difference []model.RejectedRecord
+	for _, record := range records {
+		key := record.Reason + "|" + record.Raw
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+		difference = append(difference, record)
+	}
+	return difference
+}
@@ -0,0 +1,174 @@
+package impl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// fingerprintStore is the json file keeping the fingerprints between runs
+type fingerprintStore struct {
+	Files []model.FileFingerprint `json:"files"`
+	Rows  map[string][]string     `json:"rows"`
+}
+
+// contentHash is the sha256 of the file content
+func contentHash(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("open %s: no such file or directory", filePath)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("read %s: %w", filePath, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// rowHash identifies an accepted row by its parsed values, so the same row written with another
+// amount format or column layout has the same hash. 8 bytes of the sha256 are kept.
+func rowHash(record profiledRecord) string {
+	hash := sha256.Sum256([]byte(record.key + "|" + strconv.FormatFloat(record.amount, 'f', -1, 64) + "|" + record.direction))
+	return hex.EncodeToString(hash[:8])
+}
+
+// rowSource is the scope of the row fingerprints, rows of different banks never duplicate each other
+func rowSource(stats model.FileStats) string {
+	if stats.BankName != "" {
+		return stats.BankName
+	}
+	return stats.RecordType
+}
+
+// admitFile decides whether a loaded file is kept. A file with the same content as a file loaded before is refused,
+// otherwise the rows already loaded from another file are dropped. The decision is added to the run metadata.
+func admitFile[T any](loaded *loadedFile[T]) bool {
+	stats := loaded.profile.FileStats
+	decision := model.IngestionDecision{
+		FileFingerprint: model.FileFingerprint{
+			File:        stats.FilePath,
+			RecordType:  stats.RecordType,
+			BankName:    stats.BankName,
+			ContentHash: loaded.contentHash,
+			LoadedAt:    model.Run.StartedAt,
+		},
+		Decision: model.IngestionLoaded,
+	}
+
+	if previous, exists := model.FileFingerprints[loaded.contentHash]; exists {
+		decision.Decision = model.IngestionRefused
+		decision.DuplicateOf = previous.File
+		decision.Message = fmt.Sprintf("same content as %s loaded at %s", previous.File, previous.LoadedAt)
+		model.Run.Ingestion = append(model.Run.Ingestion, decision)
+		return false
+	}
+
+	source := rowSource(stats)
+	seen := model.RowFingerprints[source]
+	if seen == nil {
+		seen = make(map[string]struct{})
+		model.RowFingerprints[source] = seen
+	}
+
+	// Rows repeated within the file are kept, only rows seen in other files are dropped
+	records := loaded.records[:0]
+	for i, record := range loaded.records {
+		if _, duplicate := seen[loaded.rowHashes[i]]; duplicate {
+			decision.DuplicateRows++
+			continue
+		}
+		records = append(records, record)
+	}
+	for _, hash := range loaded.rowHashes {
+		seen[hash] = struct{}{}
+	}
+	loaded.records = records
+
+	if decision.DuplicateRows > 0 {
+		loaded.profile.AcceptedRows -= decision.DuplicateRows
+		loaded.profile.DuplicateRows = decision.DuplicateRows
+		decision.Decision = model.IngestionDeduplicated
+		decision.Message = fmt.Sprintf("%d row(s) already loaded from another file were dropped", decision.DuplicateRows)
+	}
+
+	model.FileFingerprints[loaded.contentHash] = decision.FileFingerprint
+	model.Run.Ingestion = append(model.Run.Ingestion, decision)
+	return true
+}
+
+// LoadFingerprintStore adds the fingerprints persisted by previous runs, a missing store is an empty one
+func LoadFingerprintStore(filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", filePath, err)
+	}
+
+	var store fingerprintStore
+	if err := json.Unmarshal(content, &store); err != nil {
+		return fmt.Errorf("parse %s: %w", filePath, err)
+	}
+
+	for _, fingerprint := range store.Files {
+		model.FileFingerprints[fingerprint.ContentHash] = fingerprint
+	}
+	for source, hashes := range store.Rows {
+		seen := model.RowFingerprints[source]
+		if seen == nil {
+			seen = make(map[string]struct{}, len(hashes))
+			model.RowFingerprints[source] = seen
+		}
+		for _, hash := range hashes {
+			seen[hash] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// SaveFingerprintStore writes the fingerprints of this run and of previous runs
+func SaveFingerprintStore(filePath string) error {
+	store := fingerprintStore{Rows: make(map[string][]string, len(model.RowFingerprints))}
+
+	for _, fingerprint := range model.FileFingerprints {
+		store.Files = append(store.Files, fingerprint)
+	}
+	sort.Slice(store.Files, func(i, j int) bool {
+		if store.Files[i].LoadedAt != store.Files[j].LoadedAt {
+			return store.Files[i].LoadedAt < store.Files[j].LoadedAt
+		}
+		return store.Files[i].File < store.Files[j].File
+	})
+
+	for source, seen := range model.RowFingerprints {
+		hashes := make([]string, 0, len(seen))
+		for hash := range seen {
+			hashes = append(hashes, hash)
+		}
+		sort.Strings(hashes)
+		store.Rows[source] = hashes
+	}
+
+	content, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode fingerprints: %w", err)
+	}
+
+	if err := os.WriteFile(filePath, content, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", filePath, err)
+	}
+	return nil
+}
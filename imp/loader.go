@@ -0,0 +1,232 @@
+package impl
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/util"
+	"github.com/sientong/reconciliation-service/validator"
+)
+
+// LoaderWorkers is the number of goroutines parsing the rows of a single file
+var LoaderWorkers = runtime.NumCPU()
+
+// LoaderFileWorkers is the number of bank statement files loaded at the same time
+var LoaderFileWorkers = runtime.NumCPU()
+
+// LoaderBatchSize is the number of rows handed to a parsing worker at once.
+// At most 2 * LoaderWorkers batches are held in memory per file.
+var LoaderBatchSize = 1000
+
+// rowParser turns a row into a record, it also returns the warnings raised while validating the row
+type rowParser[T any] func(row []string) (T, []validator.Violation, error)
+
+type parsedRow[T any] struct {
+	line     int
+	row      []string
+	record   T
+	warnings []validator.Violation
+	err      error
+}
+
+// loadedFile is the result of loading a single input file
+type loadedFile[T any] struct {
+	records     []T
+	rowHashes   []string // one per record
+	contentHash string
+	rejected    []model.RejectedRecord
+	warnings    []model.RecordWarning
+	profile     model.FileProfile
+}
+
+type rowBatch struct {
+	seq   int
+	lines []int
+	rows  [][]string
+	errs  []error
+}
+
+type parsedBatch[T any] struct {
+	seq  int
+	rows []parsedRow[T]
+}
+
+// streamCSV reads a csv file row by row, parses the rows in a worker pool and hands the
+// parsed rows to collect in file order. newParser receives the header row.
+func streamCSV[T any](filePath string, newParser func(header []string) (rowParser[T], error), collect func(parsedRow[T])) (model.Dialect, error) {
+	csvReader, err := util.OpenCSV(filePath)
+	if err != nil {
+		return model.Dialect{}, err
+	}
+	defer csvReader.Close()
+
+	csvReader.FieldsPerRecord = -1 // Column count is checked per row
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return csvReader.Dialect, nil
+	}
+	if err != nil {
+		return csvReader.Dialect, fmt.Errorf("read %s: %w", filePath, err)
+	}
+
+	parse, err := newParser(header)
+	if err != nil {
+		return csvReader.Dialect, fmt.Errorf("read %s: %w", filePath, err)
+	}
+
+	workers := max(LoaderWorkers, 1)
+	batchSize := max(LoaderBatchSize, 1)
+
+	batches := make(chan rowBatch, workers)
+	parsed := make(chan parsedBatch[T], workers)
+	inFlight := make(chan struct{}, 2*workers) // Bounds the batches waiting to be collected
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				result := parsedBatch[T]{seq: batch.seq, rows: make([]parsedRow[T], len(batch.rows))}
+				for i, row := range batch.rows {
+					if batch.errs[i] != nil {
+						result.rows[i] = parsedRow[T]{line: batch.lines[i], row: row, err: batch.errs[i]}
+						continue
+					}
+					record, warnings, err := parse(row)
+					result.rows[i] = parsedRow[T]{line: batch.lines[i], row: row, record: record, warnings: warnings, err: err}
+				}
+				parsed <- result
+			}
+		}()
+	}
+
+	// Read rows and feed batches
+	var readErr error
+	go func() {
+		defer close(batches)
+
+		batch := rowBatch{}
+		for {
+			row, err := csvReader.Read()
+			if err == io.EOF {
+				break
+			}
+
+			var line int
+			var rowErr error
+			var parseErr *csv.ParseError
+			switch {
+			case errors.As(err, &parseErr):
+				// Malformed rows are kept so they can be rejected in file order
+				line, rowErr = parseErr.StartLine, parseErr
+			case err != nil:
+				readErr = fmt.Errorf("read %s: %w", filePath, err)
+			default:
+				line, _ = csvReader.FieldPos(0)
+			}
+			if readErr != nil {
+				break
+			}
+
+			batch.lines = append(batch.lines, line)
+			batch.rows = append(batch.rows, row)
+			batch.errs = append(batch.errs, rowErr)
+
+			if len(batch.rows) == batchSize {
+				inFlight <- struct{}{}
+				batches <- batch
+				batch = rowBatch{seq: batch.seq + 1}
+			}
+		}
+
+		if len(batch.rows) > 0 {
+			inFlight <- struct{}{}
+			batches <- batch
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(parsed)
+	}()
+
+	// Collect batches in file order
+	pending := make(map[int]parsedBatch[T])
+	next := 0
+	for batch := range parsed {
+		pending[batch.seq] = batch
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			for _, row := range ready.rows {
+				collect(row)
+			}
+			<-inFlight
+			next++
+		}
+	}
+
+	return csvReader.Dialect, readErr
+}
+
+// loadFile streams a file and keeps the accepted records, the rejected rows and the warnings apart.
+// describe tells the file profile what an accepted record contains. control, when set, sees every row first
+// and returns true for control rows such as balances, which are neither records nor rejected rows.
+func loadFile[T any](filePath string, stats model.FileStats, newParser func(header []string) (rowParser[T], error), describe func(T) profiledRecord, control func(parsedRow[T]) bool) (*loadedFile[T], error) {
+	loaded := &loadedFile[T]{profile: newFileProfile(stats)}
+
+	hash, err := contentHash(filePath)
+	if err != nil {
+		return nil, err
+	}
+	loaded.contentHash = hash
+
+	loaded.profile.Dialect, err = streamCSV(filePath, newParser, func(row parsedRow[T]) {
+		if control != nil && control(row) {
+			return
+		}
+
+		loaded.profile.TotalRows++
+
+		if row.err != nil {
+			rejected := newRejectedRecord(filePath, row.line, row.row, row.err)
+			if rejected.Reason == model.ReasonOutOfRange {
+				loaded.profile.OutOfRangeRows++
+			} else {
+				loaded.profile.RejectedRows++
+			}
+			profileRejected(&loaded.profile, rejected.Reason)
+			loaded.rejected = append(loaded.rejected, rejected)
+			return
+		}
+
+		described := describe(row.record)
+		loaded.profile.AcceptedRows++
+		profileRecord(&loaded.profile, described)
+		loaded.records = append(loaded.records, row.record)
+		loaded.rowHashes = append(loaded.rowHashes, rowHash(described))
+		loaded.warnings = append(loaded.warnings, newRecordWarnings(filePath, row.line, row.warnings)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loaded, nil
+}
+
+// addLoadedFile keeps the rejected rows, warnings and profile of a loaded file
+func addLoadedFile[T any](loaded *loadedFile[T]) {
+	model.RejectedRecords = append(model.RejectedRecords, loaded.rejected...)
+	model.RecordWarnings = append(model.RecordWarnings, loaded.warnings...)
+	model.LoadedFiles = append(model.LoadedFiles, loaded.profile)
+}
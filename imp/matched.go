@@ -0,0 +1,47 @@
+package impl
+
+import (
+	"math"
+	"time"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// MatchedPairSink receives the matched pairs of a run, it must be safe for concurrent use
+type MatchedPairSink interface {
+	WriteMatchedPair(pair model.MatchedPair)
+}
+
+// MatchedPairOutput, when set, receives the matched pairs instead of the output, so large runs do not hold them in memory
+var MatchedPairOutput MatchedPairSink
+
+func newMatchedPair(transaction *model.InternalTransactionRecord, bankName string, bankRecord *model.BankStatementRecord, rule string) model.MatchedPair {
+	pair := model.MatchedPair{
+		System:      *transaction,
+		BankName:    bankName,
+		Bank:        *bankRecord,
+		Rule:        rule,
+		AmountDelta: math.Abs(bankRecord.Amount) - math.Abs(transaction.Amount),
+	}
+
+	systemTime, systemErr := time.Parse(time.RFC3339, transaction.TransactionTime)
+	bankDate, bankErr := time.Parse("2006-01-02", bankRecord.Date)
+	if systemErr == nil && bankErr == nil {
+		systemDate := time.Date(systemTime.Year(), systemTime.Month(), systemTime.Day(), 0, 0, 0, 0, time.UTC)
+		pair.DateDeltaDays = int(bankDate.Sub(systemDate).Hours() / 24)
+	}
+
+	return pair
+}
+
+// recordMatch counts a matched pair in the breakdowns and keeps it in the output, or hands it to MatchedPairOutput when set
+func recordMatch(output *model.Output, transaction *model.InternalTransactionRecord, bankName string, bankRecord *model.BankStatementRecord, rule string) {
+	countMatch(output, transaction, bankName, bankRecord)
+
+	pair := newMatchedPair(transaction, bankName, bankRecord, rule)
+	if MatchedPairOutput != nil {
+		MatchedPairOutput.WriteMatchedPair(pair)
+		return
+	}
+	output.MatchedPairs = append(output.MatchedPairs, pair)
+}
@@ -0,0 +1,79 @@
+package impl
+
+import (
+	"fmt"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+const (
+	ThresholdMaxDiscrepancyAmount = "max_discrepancy_amount"
+	ThresholdMaxUnmatched         = "max_unmatched"
+	ThresholdMaxUnmatchedPct      = "max_unmatched_pct"
+)
+
+// ParseDiscrepancyThresholds reads the thresholds from DISCREPANCY_MAX_AMOUNT, DISCREPANCY_MAX_UNMATCHED and
+// DISCREPANCY_MAX_UNMATCHED_PCT, unset variables leave the threshold disabled
+func ParseDiscrepancyThresholds(getenv func(string) string) (model.DiscrepancyThresholds, error) {
+	var thresholds model.DiscrepancyThresholds
+	var err error
+
+	if thresholds.MaxAmount, err = parseFloatSetting(getenv, "DISCREPANCY_MAX_AMOUNT"); err != nil {
+		return thresholds, err
+	}
+	if thresholds.MaxUnmatched, err = parseIntSetting(getenv, "DISCREPANCY_MAX_UNMATCHED"); err != nil {
+		return thresholds, err
+	}
+	if thresholds.MaxUnmatchedPct, err = parseFloatSetting(getenv, "DISCREPANCY_MAX_UNMATCHED_PCT"); err != nil {
+		return thresholds, err
+	}
+
+	return thresholds, nil
+}
+
+// SetOutcome tells whether the run is fully reconciled, has unmatched items, or has more discrepancies
+// than the thresholds allow, and keeps the thresholds crossed in the output
+func SetOutcome(output *model.Output, thresholds model.DiscrepancyThresholds) {
+	var breaches []model.QualityBreach
+
+	if thresholds.MaxAmount != nil && output.TotalDiscrepancies > *thresholds.MaxAmount {
+		breaches = append(breaches, model.QualityBreach{
+			Threshold: ThresholdMaxDiscrepancyAmount,
+			Limit:     *thresholds.MaxAmount,
+			Actual:    output.TotalDiscrepancies,
+			Message:   fmt.Sprintf("discrepancies of %.2f exceed the maximum of %.2f", output.TotalDiscrepancies, *thresholds.MaxAmount),
+		})
+	}
+
+	if thresholds.MaxUnmatched != nil && output.TotalUnmatchedTransactions > *thresholds.MaxUnmatched {
+		breaches = append(breaches, model.QualityBreach{
+			Threshold: ThresholdMaxUnmatched,
+			Limit:     float64(*thresholds.MaxUnmatched),
+			Actual:    float64(output.TotalUnmatchedTransactions),
+			Message:   fmt.Sprintf("%d unmatched items exceed the maximum of %d", output.TotalUnmatchedTransactions, *thresholds.MaxUnmatched),
+		})
+	}
+
+	if thresholds.MaxUnmatchedPct != nil {
+		unmatchedPct := percentage(output.TotalUnmatchedTransactions, output.TotalProcessedRecords)
+		if unmatchedPct > *thresholds.MaxUnmatchedPct {
+			breaches = append(breaches, model.QualityBreach{
+				Threshold: ThresholdMaxUnmatchedPct,
+				Limit:     *thresholds.MaxUnmatchedPct,
+				Actual:    unmatchedPct,
+				Message: fmt.Sprintf("%.2f%% unmatched items (%d of %d) exceeds the maximum of %.2f%%",
+					unmatchedPct, output.TotalUnmatchedTransactions, output.TotalProcessedRecords, *thresholds.MaxUnmatchedPct),
+			})
+		}
+	}
+
+	output.DiscrepancyBreaches = breaches
+	switch {
+	case len(breaches) > 0:
+		output.Outcome = model.OutcomeDiscrepancy
+	case output.TotalUnmatchedTransactions > 0:
+		output.Outcome = model.OutcomeUnmatched
+	default:
+		output.Outcome = model.OutcomeReconciled
+	}
+}
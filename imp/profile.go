@@ -0,0 +1,79 @@
+package impl
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// ProfileLargestAmounts is the number of largest amounts kept in a file profile
+var ProfileLargestAmounts = 5
+
+// profiledRecord is what the profile and the row fingerprint need to know about an accepted record
+type profiledRecord struct {
+	key        string // identifier and full date or time of the record
+	identifier string
+	date       string // YYYY-MM-DD
+	amount     float64
+	direction  string
+}
+
+func newFileProfile(stats model.FileStats) model.FileProfile {
+	return model.FileProfile{
+		FileStats:        stats,
+		RejectedByReason: make(map[string]int),
+		CountPerDay:      make(map[string]int),
+	}
+}
+
+func profileRejected(profile *model.FileProfile, reason string) {
+	profile.RejectedByReason[reason]++
+}
+
+func profileRecord(profile *model.FileProfile, record profiledRecord) {
+	if profile.MinDate == "" || record.date < profile.MinDate {
+		profile.MinDate = record.date
+	}
+	if record.date > profile.MaxDate {
+		profile.MaxDate = record.date
+	}
+
+	profile.CountPerDay[record.date]++
+
+	if record.direction == model.DirectionDebit {
+		profile.SumDebits += math.Abs(record.amount)
+	} else {
+		profile.SumCredits += math.Abs(record.amount)
+	}
+
+	// Keep the largest absolute amounts, largest first
+	largest := profile.LargestAmounts
+	if len(largest) == ProfileLargestAmounts && math.Abs(record.amount) <= math.Abs(largest[len(largest)-1].Amount) {
+		return
+	}
+
+	i := sort.Search(len(largest), func(i int) bool {
+		return math.Abs(largest[i].Amount) < math.Abs(record.amount)
+	})
+	largest = append(largest, model.ProfiledAmount{})
+	copy(largest[i+1:], largest[i:])
+	largest[i] = model.ProfiledAmount{Identifier: record.identifier, Amount: record.amount, Date: record.date}
+
+	if len(largest) > ProfileLargestAmounts {
+		largest = largest[:ProfileLargestAmounts]
+	}
+	profile.LargestAmounts = largest
+}
+
+func profileSystemTransaction(record *model.InternalTransactionRecord) profiledRecord {
+	date := record.TransactionTime
+	if len(date) >= 10 {
+		date = date[:10]
+	}
+	return profiledRecord{key: record.TrxID + "|" + record.TransactionTime, identifier: record.TrxID, date: date, amount: record.Amount, direction: record.Type}
+}
+
+func profileBankStatement(record *model.BankStatementRecord) profiledRecord {
+	return profiledRecord{key: record.UniqueIdentifier + "|" + record.Date, identifier: record.UniqueIdentifier, date: record.Date, amount: record.Amount, direction: record.Direction}
+}
@@ -0,0 +1,30 @@
+package impl
+
+import (
+	"fmt"
+
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/util"
+)
+
+// SetBankAmountFormat assigns a named amount format to a bank
+func SetBankAmountFormat(bankName string, formatName string) error {
+	format, err := util.GetAmountFormat(formatName)
+	if err != nil {
+		return fmt.Errorf("bank %s: %w", bankName, err)
+	}
+
+	profile := model.BankProfiles[bankName]
+	profile.Name = bankName
+	profile.AmountFormat = format
+	model.BankProfiles[bankName] = profile
+
+	return nil
+}
+
+func bankAmountFormat(bankName string) model.AmountFormat {
+	if profile, exists := model.BankProfiles[bankName]; exists && profile.AmountFormat.Name != "" {
+		return profile.AmountFormat
+	}
+	return util.DefaultAmountFormat
+}
@@ -0,0 +1,137 @@
+package impl
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+const (
+	ThresholdMaxRejectedPct   = "max_rejected_pct"
+	ThresholdMinRowCount      = "min_row_count"
+	ThresholdMaxOutOfRangePct = "max_out_of_range_pct"
+)
+
+// CheckDataQuality compares the loaded files against the thresholds, per file and for all files together
+func CheckDataQuality(thresholds model.DataQualityThresholds) []model.QualityBreach {
+	var breaches []model.QualityBreach
+
+	total := model.FileStats{}
+	for _, profile := range model.LoadedFiles {
+		stats := profile.FileStats
+		breaches = append(breaches, checkFileQuality(stats, stats.FilePath, thresholds.PerFile)...)
+
+		total.TotalRows += stats.TotalRows
+		total.AcceptedRows += stats.AcceptedRows
+		total.RejectedRows += stats.RejectedRows
+		total.OutOfRangeRows += stats.OutOfRangeRows
+	}
+
+	breaches = append(breaches, checkFileQuality(total, "", thresholds.Global)...)
+
+	return breaches
+}
+
+func checkFileQuality(stats model.FileStats, file string, thresholds model.QualityThresholds) []model.QualityBreach {
+	var breaches []model.QualityBreach
+
+	name := file
+	if name == "" {
+		name = "all files"
+	}
+
+	if thresholds.MinRowCount != nil && stats.TotalRows < *thresholds.MinRowCount {
+		breaches = append(breaches, model.QualityBreach{
+			File:      file,
+			Threshold: ThresholdMinRowCount,
+			Limit:     float64(*thresholds.MinRowCount),
+			Actual:    float64(stats.TotalRows),
+			Message:   fmt.Sprintf("%s: %d rows is below the minimum of %d rows", name, stats.TotalRows, *thresholds.MinRowCount),
+		})
+	}
+
+	if thresholds.MaxRejectedPct != nil {
+		rejectedPct := percentage(stats.RejectedRows, stats.TotalRows)
+		if rejectedPct > *thresholds.MaxRejectedPct {
+			breaches = append(breaches, model.QualityBreach{
+				File:      file,
+				Threshold: ThresholdMaxRejectedPct,
+				Limit:     *thresholds.MaxRejectedPct,
+				Actual:    rejectedPct,
+				Message: fmt.Sprintf("%s: %.2f%% rejected rows (%d of %d) exceeds the maximum of %.2f%%",
+					name, rejectedPct, stats.RejectedRows, stats.TotalRows, *thresholds.MaxRejectedPct),
+			})
+		}
+	}
+
+	if thresholds.MaxOutOfRangePct != nil {
+		outOfRangePct := percentage(stats.OutOfRangeRows, stats.TotalRows)
+		if outOfRangePct > *thresholds.MaxOutOfRangePct {
+			breaches = append(breaches, model.QualityBreach{
+				File:      file,
+				Threshold: ThresholdMaxOutOfRangePct,
+				Limit:     *thresholds.MaxOutOfRangePct,
+				Actual:    outOfRangePct,
+				Message: fmt.Sprintf("%s: %.2f%% rows outside the date range (%d of %d) exceeds the maximum of %.2f%%",
+					name, outOfRangePct, stats.OutOfRangeRows, stats.TotalRows, *thresholds.MaxOutOfRangePct),
+			})
+		}
+	}
+
+	return breaches
+}
+
+func percentage(count int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(total)
+}
+
+// ParseQualityThresholds reads the thresholds from variables such as DQ_MAX_REJECTED_PCT and
+// DQ_GLOBAL_MAX_REJECTED_PCT, unset variables leave the threshold disabled
+func ParseQualityThresholds(getenv func(string) string) (model.DataQualityThresholds, error) {
+	var thresholds model.DataQualityThresholds
+	var err error
+
+	for prefix, target := range map[string]*model.QualityThresholds{"DQ_": &thresholds.PerFile, "DQ_GLOBAL_": &thresholds.Global} {
+		if target.MaxRejectedPct, err = parseFloatSetting(getenv, prefix+"MAX_REJECTED_PCT"); err != nil {
+			return thresholds, err
+		}
+		if target.MinRowCount, err = parseIntSetting(getenv, prefix+"MIN_ROWS"); err != nil {
+			return thresholds, err
+		}
+		if target.MaxOutOfRangePct, err = parseFloatSetting(getenv, prefix+"MAX_OUT_OF_RANGE_PCT"); err != nil {
+			return thresholds, err
+		}
+	}
+
+	return thresholds, nil
+}
+
+func parseFloatSetting(getenv func(string) string, name string) (*float64, error) {
+	raw := getenv(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("invalid %s %s, expected a non negative number", name, raw)
+	}
+	return &value, nil
+}
+
+func parseIntSetting(getenv func(string) string, name string) (*int, error) {
+	raw := getenv(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return nil, fmt.Errorf("invalid %s %s, expected a non negative integer", name, raw)
+	}
+	return &value, nil
+}
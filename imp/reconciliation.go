@@ -1,21 +1,51 @@
 package impl
 
 import (
+	"fmt"
 	"math"
 	"runtime"
+	"runtime/debug"
 	"sync"
 
 	"github.com/sientong/reconciliation-service/model"
 	"github.com/sientong/reconciliation-service/util"
 )
 
+// Workers is the number of workers of the concurrent strategies, 0 uses twice the number of CPUs
+var Workers = 0
+
+func workerCount() int {
+	if Workers > 0 {
+		return Workers
+	}
+	return 2 * runtime.NumCPU()
+}
+
+// workerFailure keeps the first panic of the workers of a strategy, so it is returned as an error instead of
+// crashing the process with the exit status of a discrepancy
+type workerFailure struct {
+	once sync.Once
+	err  error
+}
+
+// recover is deferred by each worker, after a panic the worker drains the jobs so the feeder is not blocked
+func (f *workerFailure) recover(jobs <-chan *model.InternalTransactionRecord) {
+	if recovered := recover(); recovered != nil {
+		f.once.Do(func() {
+			f.err = fmt.Errorf("reconciliation worker failed: %v\n%s", recovered, debug.Stack())
+		})
+		for range jobs {
+		}
+	}
+}
+
 func SimpleReconciliation() (*model.Output, error) {
 
 	output := &model.Output{}
 
 	// Check for a match between system transactions and bank statements
 	for _, systemTransaction := range model.SystemTransactionRecords {
-		for _, bankRecords := range model.BankStatementRecordsMap {
+		for bankName, bankRecords := range model.BankStatementRecordsMap {
 			for _, bankRecord := range bankRecords {
 
 				if bankRecord.IsMatched || systemTransaction.Amount != math.Abs(bankRecord.Amount) {
@@ -38,40 +68,38 @@ func SimpleReconciliation() (*model.Output, error) {
 					continue
 				}
 
-				if systemTransaction.Type == "debit" && bankRecord.Amount > 0 {
-					continue
-				}
-
-				if systemTransaction.Type == "credit" && bankRecord.Amount < 0 {
+				if systemTransaction.Type != bankRecord.Direction {
 					continue
 				}
 
 				systemTransaction.IsMatched = true
 				bankRecord.IsMatched = true
 				output.TotalMatchedTransactions++
+				recordMatch(output, systemTransaction, bankName, bankRecord, model.MatchRuleExact)
+				break
+			}
+
+			if systemTransaction.IsMatched {
+				break
 			}
 		}
 
 		// If no bank statement is matched with systm transaction, add to unmatched transaction
 		if !systemTransaction.IsMatched {
-			output.UnmatchedSystemTransactions = append(output.UnmatchedSystemTransactions, *systemTransaction)
-			// fmt.Printf("Unmatched System Transaction: %s on %s\n", systemTransaction.TrxID, systemTransaction.TransactionTime)
-
-			output.TotalDiscrepancies += math.Abs(systemTransaction.Amount)
-			output.TotalUnmatchedSystemTransactions++
-			output.TotalUnmatchedTransactions++
+			recordUnmatchedSystemTransaction(output, systemTransaction)
 		}
 
 		output.TotalProcessedRecords++
 	}
 
 	collectUnmatchedBankStmts(output)
+	collectLoadResults(output)
 
 	return output, nil
 }
 
 func ConcurrentReconcilliation() (*model.Output, error) {
-	workers := 2 * runtime.NumCPU()
+	workers := workerCount()
 
 	// Bank locks to protect each bank’s records
 	bankLocks := make(map[string]*sync.Mutex, len(model.BankStatementRecordsMap))
@@ -83,12 +111,14 @@ func ConcurrentReconcilliation() (*model.Output, error) {
 	results := make(chan *model.Output) // Per-worker results
 
 	var wg sync.WaitGroup
+	var failure workerFailure
 
 	// Start workers
 	wg.Add(workers)
 	for range workers {
 		go func() {
 			defer wg.Done()
+			defer failure.recover(jobs)
 			localOutput := &model.Output{}
 
 			for trx := range jobs {
@@ -120,8 +150,13 @@ func ConcurrentReconcilliation() (*model.Output, error) {
 		mergeOutput(finalOutput, localOut)
 	}
 
+	if failure.err != nil {
+		return nil, failure.err
+	}
+
 	// Collect unmatched bank statements (still single-threaded)
 	collectUnmatchedBankStmts(finalOutput)
+	collectLoadResults(finalOutput)
 
 	return finalOutput, nil
 }
@@ -140,49 +175,59 @@ func processTransactionLocal(
 	}
 
 	for bankName, bankRecords := range model.BankStatementRecordsMap {
-		lock := bankLocks[bankName]
-
-		lock.Lock()
-		for _, bankRecord := range bankRecords {
-			if bankRecord.IsMatched || transaction.Amount != math.Abs(bankRecord.Amount) {
-				continue
-			}
+		if matchInBank(transaction, transactionDate, bankName, bankRecords, bankLocks[bankName], localOutput) {
+			break
+		}
+	}
 
-			if transaction.Type == "debit" && bankRecord.Amount > 0 {
-				continue
-			}
+	localOutput.TotalProcessedRecords++
 
-			if transaction.Type == "credit" && bankRecord.Amount < 0 {
-				continue
-			}
+	if !transaction.IsMatched {
+		recordUnmatchedSystemTransaction(localOutput, transaction)
+	}
+}
 
-			bankRecordDate, err := util.ConvertBankStatementDate(bankRecord.Date)
-			if err != nil || transactionDate != bankRecordDate {
-				localOutput.TotalInvalidRecords++
-				continue
-			}
+// matchInBank matches the transaction with the first unmatched record of a bank, holding the lock of the bank.
+// The lock is released by a defer, so a panic does not leave the other workers waiting for it.
+func matchInBank(
+	transaction *model.InternalTransactionRecord,
+	transactionDate string,
+	bankName string,
+	bankRecords []*model.BankStatementRecord,
+	lock *sync.Mutex,
+	localOutput *model.Output) bool {
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, bankRecord := range bankRecords {
+		if bankRecord.IsMatched || transaction.Amount != math.Abs(bankRecord.Amount) {
+			continue
+		}
 
-			transaction.IsMatched = true
-			bankRecord.IsMatched = true
+		if transaction.Type != bankRecord.Direction {
+			continue
+		}
 
-			localOutput.TotalMatchedTransactions++
-			break
+		bankRecordDate, err := util.ConvertBankStatementDate(bankRecord.Date)
+		if err != nil {
+			localOutput.TotalInvalidRecords++
+			continue
 		}
-		lock.Unlock()
 
-		if transaction.IsMatched {
-			break
+		if transactionDate != bankRecordDate {
+			continue
 		}
-	}
 
-	localOutput.TotalProcessedRecords++
+		transaction.IsMatched = true
+		bankRecord.IsMatched = true
 
-	if !transaction.IsMatched {
-		localOutput.UnmatchedSystemTransactions = append(localOutput.UnmatchedSystemTransactions, *transaction)
-		localOutput.TotalDiscrepancies += math.Abs(transaction.Amount)
-		localOutput.TotalUnmatchedSystemTransactions++
-		localOutput.TotalUnmatchedTransactions++
+		localOutput.TotalMatchedTransactions++
+		recordMatch(localOutput, transaction, bankName, bankRecord, model.MatchRuleExact)
+		return true
 	}
+
+	return false
 }
 
 func mergeOutput(final *model.Output, local *model.Output) {
@@ -193,11 +238,14 @@ func mergeOutput(final *model.Output, local *model.Output) {
 	final.TotalUnmatchedSystemTransactions += local.TotalUnmatchedSystemTransactions
 	final.TotalDiscrepancies += local.TotalDiscrepancies
 
-	// Combine unmatched slices
+	// Combine matched and unmatched slices
+	final.MatchedPairs = append(final.MatchedPairs, local.MatchedPairs...)
 	final.UnmatchedSystemTransactions = append(final.UnmatchedSystemTransactions, local.UnmatchedSystemTransactions...)
+
+	mergeBreakdowns(final, local)
 }
 
-// Unprocessed bank statement is treated as unmatched
+// Unprocessed bank statement is treated as unmatched, it is the last record counted in the breakdowns
 func collectUnmatchedBankStmts(output *model.Output) {
 	for bankName, bankRecords := range model.BankStatementRecordsMap {
 		for _, bankRecord := range bankRecords {
@@ -218,21 +266,36 @@ func collectUnmatchedBankStmts(output *model.Output) {
 			output.TotalUnmatchedTransactions++
 			output.TotalUnmatchedBankStmts++
 			output.TotalProcessedRecords++
+			countUnmatchedBankStmt(output, bankName, bankRecord)
 		}
 	}
+
+	computeMatchRates(output)
+}
+
+// Rows rejected while loading the files are counted as invalid records, warnings, file profiles and balance checks are only reported
+func collectLoadResults(output *model.Output) {
+	output.RejectedRecords = append(output.RejectedRecords, model.RejectedRecords...)
+	output.RecordWarnings = append(output.RecordWarnings, model.RecordWarnings...)
+	output.FileProfiles = append(output.FileProfiles, model.LoadedFiles...)
+	output.BalanceChecks = append(output.BalanceChecks, model.BalanceChecks...)
+	output.TotalInvalidRecords += len(model.RejectedRecords)
+	output.Metadata = model.Run
 }
 
 func ConcurrentReconciliationIndexed() (*model.Output, error) {
-	workers := 2 * runtime.NumCPU()
+	workers := workerCount()
 	index := BuildBankIndex()
 
 	jobs := make(chan *model.InternalTransactionRecord)
 	results := make(chan *model.Output)
 
 	var wg sync.WaitGroup
+	var failure workerFailure
 
 	worker := func() {
 		defer wg.Done()
+		defer failure.recover(jobs)
 		localOut := &model.Output{}
 
 		for trx := range jobs {
@@ -266,7 +329,12 @@ func ConcurrentReconciliationIndexed() (*model.Output, error) {
 		mergeOutput(finalOutput, res)
 	}
 
+	if failure.err != nil {
+		return nil, failure.err
+	}
+
 	collectUnmatchedBankStmts(finalOutput)
+	collectLoadResults(finalOutput)
 	return finalOutput, nil
 }
 
@@ -287,31 +355,22 @@ func processTransactionIndexed(
 	// Lookup possible matches
 	dateBucket, ok := idx.Index[transactionDate]
 	if !ok {
-		localOutput.TotalUnmatchedSystemTransactions++
 		localOutput.TotalProcessedRecords++
-		localOutput.UnmatchedSystemTransactions = append(localOutput.UnmatchedSystemTransactions, *transaction)
-		localOutput.TotalDiscrepancies += amount
-		localOutput.TotalUnmatchedTransactions++
+		recordUnmatchedSystemTransaction(localOutput, transaction)
 		return
 	}
 
 	amtBucket, ok := dateBucket[amount]
 	if !ok {
-		localOutput.TotalUnmatchedSystemTransactions++
 		localOutput.TotalProcessedRecords++
-		localOutput.UnmatchedSystemTransactions = append(localOutput.UnmatchedSystemTransactions, *transaction)
-		localOutput.TotalDiscrepancies += amount
-		localOutput.TotalUnmatchedTransactions++
+		recordUnmatchedSystemTransaction(localOutput, transaction)
 		return
 	}
 
 	typeBucket, ok := amtBucket[txType]
 	if !ok {
-		localOutput.TotalUnmatchedSystemTransactions++
 		localOutput.TotalProcessedRecords++
-		localOutput.UnmatchedSystemTransactions = append(localOutput.UnmatchedSystemTransactions, *transaction)
-		localOutput.TotalDiscrepancies += amount
-		localOutput.TotalUnmatchedTransactions++
+		recordUnmatchedSystemTransaction(localOutput, transaction)
 		return
 	}
 
@@ -325,15 +384,13 @@ func processTransactionIndexed(
 			rec.IsMatched = true
 			transaction.IsMatched = true
 			localOutput.TotalMatchedTransactions++
+			recordMatch(localOutput, transaction, idx.Banks[rec], rec, model.MatchRuleExact)
 			break
 		}
 	}
 
 	localOutput.TotalProcessedRecords++
 	if !transaction.IsMatched {
-		localOutput.TotalUnmatchedSystemTransactions++
-		localOutput.TotalUnmatchedTransactions++
-		localOutput.TotalDiscrepancies += amount
-		localOutput.UnmatchedSystemTransactions = append(localOutput.UnmatchedSystemTransactions, *transaction)
+		recordUnmatchedSystemTransaction(localOutput, transaction)
 	}
 }
@@ -1,24 +1,36 @@
 package impl
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sientong/reconciliation-service/model"
 	"github.com/sientong/reconciliation-service/util"
 	validator "github.com/sientong/reconciliation-service/validator"
 )
 
+// LogWriter receives the progress lines of loading and reconciling
+var LogWriter io.Writer = os.Stdout
+
 func InitModel() {
 	// Initialize the model and other necessary components
 	model.SystemTransactionRecords = []*model.InternalTransactionRecord{}
 	model.BankStatementRecordsMap = make(map[string][]*model.BankStatementRecord)
+	model.RejectedRecords = nil
+	model.RecordWarnings = nil
+	model.LoadedFiles = nil
+	model.BalanceChecks = nil
+	model.Run = model.RunMetadata{StartedAt: time.Now().UTC().Format(time.RFC3339)}
+	model.FileFingerprints = make(map[string]model.FileFingerprint)
+	model.RowFingerprints = make(map[string]map[string]struct{})
 }
 
 func CreateRecords(filePath string, recordType string, startDate string, endDate string) error {
@@ -29,8 +41,8 @@ func CreateRecords(filePath string, recordType string, startDate string, endDate
 			return fmt.Errorf("failed to create system transactions records: %w", err)
 		}
 	case "bankStatement":
-		if err := createBankStatementRecords(filePath, startDate, endDate); err != nil {
-			return fmt.Errorf("failed to create bank statement records: %w", err)
+		if err := CreateBankStatementRecords([]string{filePath}, startDate, endDate); err != nil {
+			return err
 		}
 	default:
 		return fmt.Errorf("unknown record type: %s", recordType)
@@ -40,152 +52,352 @@ func CreateRecords(filePath string, recordType string, startDate string, endDate
 }
 
 func createSystemTransactionsRecords(filePath string, startDate string, endDate string) error {
-	fmt.Println("Creating system transaction records from:", filePath)
+	fmt.Fprintln(LogWriter, "Creating system transaction records from:", filePath)
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("open %s: no such file or directory", filePath)
+	newParser := func(header []string) (rowParser[*model.InternalTransactionRecord], error) {
+		mapped, err := validator.MapHeader(header, "systemTransaction")
+		if err != nil {
+			return nil, err
+		}
+
+		return func(row []string) (*model.InternalTransactionRecord, []validator.Violation, error) {
+			return parseSystemTransactionRecord(row, mapped, startDate, endDate)
+		}, nil
 	}
-	defer file.Close()
 
-	csvReader := csv.NewReader(file)
-	csvRecords, err := csvReader.ReadAll()
+	stats := model.FileStats{FilePath: filePath, RecordType: "systemTransaction"}
+	loaded, err := loadFile(filePath, stats, newParser, profileSystemTransaction, nil)
 	if err != nil {
-		return fmt.Errorf("read %s: %w", filePath, err)
+		return err
 	}
 
-	for _, row := range csvRecords[1:] { // Skip header row
-		err := parseSystemTransactionRecord(row, startDate, endDate)
-		if err != nil {
-			fmt.Printf("error parsing record %v: %v\n", row, err)
-			continue
-		}
+	if !admitFile(loaded) {
+		fmt.Fprintln(LogWriter, "Skipping already loaded file:", filePath)
+		return nil
 	}
 
+	model.SystemTransactionRecords = append(model.SystemTransactionRecords, loaded.records...)
+	addLoadedFile(loaded)
+
 	return nil
 }
 
-func parseSystemTransactionRecord(record []string, startDate string, endDate string) error {
-	err := validator.ValidateRecord(record, "systemTransaction")
+func parseSystemTransactionRecord(record []string, header validator.Header, startDate string, endDate string) (*model.InternalTransactionRecord, []validator.Violation, error) {
+	fields, warnings, err := validateFields(record, header, "systemTransaction", util.DefaultAmountFormat)
 	if err != nil {
-		return fmt.Errorf("validate record %v: %w", record, err)
+		return nil, nil, err
 	}
 
-	amount, err := strconv.ParseFloat(record[1], 64)
+	amount, err := strconv.ParseFloat(fields["amount"], 64)
 	if err != nil {
-		return fmt.Errorf("parse amount %s: %w", record[1], err)
+		return nil, nil, rejectField("amount", model.ReasonBadAmount, fmt.Errorf("parse amount %s: %w", fields["amount"], err))
 	}
 
-	transactionType := strings.ToLower(record[2])
+	transactionType := strings.ToLower(fields["type"])
 	if transactionType != "credit" && transactionType != "debit" {
-		return fmt.Errorf("invalid transaction type %s, must be 'credit' or 'debit'", transactionType)
+		return nil, nil, rejectField("type", model.ReasonBadType, fmt.Errorf("invalid transaction type %s, must be 'credit' or 'debit'", transactionType))
 	}
 
 	newRecord := &model.InternalTransactionRecord{
-		TrxID:           record[0],
+		TrxID:           fields["trxID"],
 		Amount:          amount,
 		Type:            transactionType,
-		TransactionTime: record[3],
+		TransactionTime: fields["transactionTime"],
 		IsMatched:       false,
+		Extra:           extraFields(record, header),
 	}
 
 	transactionDate, err := util.ConvertSystemTransactionDate(newRecord.TransactionTime)
 	if err != nil {
-		return fmt.Errorf("error when converting transaction date %s: %w", newRecord.TransactionTime, err)
+		return nil, nil, rejectField("transactionTime", model.ReasonBadDate, fmt.Errorf("error when converting transaction date %s: %w", newRecord.TransactionTime, err))
 	}
 
 	if transactionDate < startDate || transactionDate > endDate {
-		return fmt.Errorf("transaction time %s is out of range [%s, %s]", newRecord.TransactionTime, startDate, endDate)
+		return nil, nil, rejectField("transactionTime", model.ReasonOutOfRange, fmt.Errorf("transaction time %s is out of range [%s, %s]", newRecord.TransactionTime, startDate, endDate))
 	}
 
-	model.SystemTransactionRecords = append(model.SystemTransactionRecords, newRecord)
-
-	return nil
+	return newRecord, warnings, nil
 }
 
-func createBankStatementRecords(filePath string, startDate string, endDate string) error {
-	fmt.Println("Creating bank statement records from:", filePath)
-
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("open %s: no such file or directory", filePath)
+// CreateBankStatementRecords loads several bank statement files concurrently.
+// Records are added in the order the files are given, regardless of which file finishes first.
+func CreateBankStatementRecords(filePaths []string, startDate string, endDate string) error {
+	loaded := make([]*loadedFile[*model.BankStatementRecord], len(filePaths))
+	balances := make([]model.BalanceCheck, len(filePaths))
+	errs := make([]error, len(filePaths))
+
+	files := make(chan struct{}, max(LoaderFileWorkers, 1))
+	var wg sync.WaitGroup
+
+	wg.Add(len(filePaths))
+	for i, filePath := range filePaths {
+		go func() {
+			defer wg.Done()
+			files <- struct{}{}
+			defer func() { <-files }()
+
+			loaded[i], balances[i], errs[i] = loadBankStatementRecords(filePath, startDate, endDate)
+		}()
 	}
-	defer file.Close()
+	wg.Wait()
 
-	bankName := strings.Split(filepath.Base(filePath), "_")[0] // Get bank name from file name
+	for i, filePath := range filePaths {
+		if errs[i] != nil {
+			continue
+		}
 
-	csvReader := csv.NewReader(file)
-	csvRecords, err := csvReader.ReadAll()
-	if err != nil {
-		return fmt.Errorf("read %s: %w", filePath, err)
+		if !admitFile(loaded[i]) {
+			fmt.Fprintln(LogWriter, "Skipping already loaded file:", filePath)
+			continue
+		}
+
+		addLoadedFile(loaded[i])
+		model.BalanceChecks = append(model.BalanceChecks, balances[i])
+		if len(loaded[i].records) == 0 {
+			continue
+		}
+
+		bankName := bankNameFromPath(filePath)
+		model.BankStatementRecordsMap[bankName] = append(model.BankStatementRecordsMap[bankName], loaded[i].records...)
 	}
 
-	for _, row := range csvRecords[1:] { // Skip header row
-		err := parseBankStatementRecord(row, bankName, startDate, endDate)
+	for i := range errs {
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("failed to create bank statement records: %w", errs[i])
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func loadBankStatementRecords(filePath string, startDate string, endDate string) (*loadedFile[*model.BankStatementRecord], model.BalanceCheck, error) {
+	fmt.Fprintln(LogWriter, "Creating bank statement records from:", filePath)
+
+	bankName := bankNameFromPath(filePath)
+
+	newParser := func(header []string) (rowParser[*model.BankStatementRecord], error) {
+		mapped, err := validator.MapHeader(header, "bankStatement")
 		if err != nil {
-			fmt.Printf("error parsing record %v: %v\n", row, err)
-			continue
+			return nil, err
 		}
+
+		return func(row []string) (*model.BankStatementRecord, []validator.Violation, error) {
+			return parseBankStatementRecord(row, mapped, bankName, startDate, endDate)
+		}, nil
 	}
 
-	return nil
+	// Balance rows are kept apart, out of range rows only count towards the lines total
+	var inline model.StatementBalance
+	var linesTotal float64
+	control := func(row parsedRow[*model.BankStatementRecord]) bool {
+		if row.record == nil {
+			return false
+		}
+
+		if row.err == nil && isBalanceRow(row.record.UniqueIdentifier) {
+			balance := row.record.Amount
+			if row.record.UniqueIdentifier == model.OpeningBalanceIdentifier {
+				inline.Opening = &balance
+			} else {
+				inline.Closing = &balance
+			}
+			return true
+		}
+
+		linesTotal += row.record.Amount
+		return false
+	}
+
+	stats := model.FileStats{FilePath: filePath, RecordType: "bankStatement", BankName: bankName}
+	loaded, err := loadFile(filePath, stats, newParser, profileBankStatement, control)
+	if err != nil {
+		return nil, model.BalanceCheck{}, err
+	}
+
+	return loaded, checkBalance(filePath, bankName, inline, linesTotal, loaded.profile.RejectedRows), nil
 }
 
-func parseBankStatementRecord(record []string, bankName string, startDate string, endDate string) error {
-	err := validator.ValidateRecord(record, "bankStatement")
+func parseBankStatementRecord(record []string, header validator.Header, bankName string, startDate string, endDate string) (*model.BankStatementRecord, []validator.Violation, error) {
+	format := bankAmountFormat(bankName)
+	columns := header.Columns
+
+	if i := columns["unique_identifier"]; i < len(record) && isBalanceRow(record[i]) {
+		balance, err := parseBalanceRow(record, header, format)
+		return balance, nil, err
+	}
+
+	_, warnings, err := validateFields(record, header, "bankStatement", format)
 	if err != nil {
-		return fmt.Errorf("validate record %v: %w", record, err)
+		return nil, nil, err
 	}
 
-	amount, err := strconv.ParseFloat(record[1], 64)
+	amount, direction, err := parseBankStatementAmount(record, header, format)
 	if err != nil {
-		return fmt.Errorf("parse amount %s: %w", record[1], err)
+		return nil, nil, err
 	}
 
 	newRecord := &model.BankStatementRecord{
-		UniqueIdentifier: record[0],
+		UniqueIdentifier: record[columns["unique_identifier"]],
 		Amount:           amount,
-		Date:             record[2],
+		Direction:        direction,
+		Date:             record[columns["date"]],
 		IsMatched:        false,
+		Extra:            extraFields(record, header),
 	}
 
 	transactionDate, err := util.ConvertBankStatementDate(newRecord.Date)
 	if err != nil {
-		return fmt.Errorf("error when converting bank statement date %s: %w", newRecord.Date, err)
+		return nil, nil, rejectField("date", model.ReasonBadDate, fmt.Errorf("error when converting bank statement date %s: %w", newRecord.Date, err))
 	}
 
+	// The record is still returned so its amount counts towards the statement balance
 	if transactionDate < startDate || transactionDate > endDate {
-		return fmt.Errorf("date %s is out of range [%s, %s]", newRecord.Date, startDate, endDate)
+		return newRecord, nil, rejectField("date", model.ReasonOutOfRange, fmt.Errorf("date %s is out of range [%s, %s]", newRecord.Date, startDate, endDate))
+	}
+
+	return newRecord, warnings, nil
+}
+
+// Get bank name from file name, e.g. bankA_20250605.csv
+func bankNameFromPath(filePath string) string {
+	return strings.Split(filepath.Base(filePath), "_")[0]
+}
+
+// parseBankStatementAmount normalizes the amount columns of any supported layout into a signed amount and its direction
+func parseBankStatementAmount(record []string, header validator.Header, format model.AmountFormat) (float64, string, error) {
+	columns := header.Columns
+
+	switch header.Layout {
+	case validator.BankLayoutDebitCredit:
+		debitRaw := strings.TrimSpace(record[columns["debit"]])
+		creditRaw := strings.TrimSpace(record[columns["credit"]])
+
+		var debit, credit float64
+		var err error
+		if debitRaw != "" {
+			if debit, err = util.ParseAmount(debitRaw, format); err != nil {
+				return 0, "", rejectField("debit", model.ReasonBadAmount, fmt.Errorf("parse debit: %w", err))
+			}
+		}
+		if creditRaw != "" {
+			if credit, err = util.ParseAmount(creditRaw, format); err != nil {
+				return 0, "", rejectField("credit", model.ReasonBadAmount, fmt.Errorf("parse credit: %w", err))
+			}
+		}
+
+		switch {
+		case debit != 0 && credit != 0:
+			return 0, "", rejectField("debit", model.ReasonBadAmount, fmt.Errorf("both debit %s and credit %s are filled in", debitRaw, creditRaw))
+		case debit != 0:
+			return -math.Abs(debit), model.DirectionDebit, nil
+		case credit != 0:
+			return math.Abs(credit), model.DirectionCredit, nil
+		default:
+			return 0, "", rejectField("debit", model.ReasonBadAmount, fmt.Errorf("neither debit nor credit is filled in"))
+		}
+
+	case validator.BankLayoutIndicator:
+		amount, err := util.ParseAmount(record[columns["amount"]], format)
+		if err != nil {
+			return 0, "", rejectField("amount", model.ReasonBadAmount, fmt.Errorf("parse amount: %w", err))
+		}
+
+		direction, err := parseDirectionIndicator(record[columns["dc"]])
+		if err != nil {
+			return 0, "", rejectField("dc", model.ReasonBadType, err)
+		}
+
+		if amount < 0 && direction == model.DirectionCredit {
+			return 0, "", rejectField("amount", model.ReasonBadAmount, fmt.Errorf("negative amount %s conflicts with credit indicator", record[columns["amount"]]))
+		}
+
+		if direction == model.DirectionDebit {
+			return -math.Abs(amount), direction, nil
+		}
+		return amount, direction, nil
+
+	default:
+		amount, err := util.ParseAmount(record[columns["amount"]], format)
+		if err != nil {
+			return 0, "", rejectField("amount", model.ReasonBadAmount, fmt.Errorf("parse amount: %w", err))
+		}
+
+		if amount < 0 {
+			return amount, model.DirectionDebit, nil
+		}
+		return amount, model.DirectionCredit, nil
+	}
+}
+
+func parseDirectionIndicator(indicator string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(indicator)) {
+	case "D", "DR", "DB", "DEBIT":
+		return model.DirectionDebit, nil
+	case "C", "CR", "CREDIT":
+		return model.DirectionCredit, nil
+	default:
+		return "", fmt.Errorf("invalid debit/credit indicator %s, expected D or C", indicator)
 	}
+}
 
-	if _, exists := model.BankStatementRecordsMap[bankName]; !exists {
-		model.BankStatementRecordsMap[bankName] = []*model.BankStatementRecord{}
+// validateFields checks the column count and runs the record rules, a rule with reject severity rejects the row
+func validateFields(record []string, header validator.Header, recordType string, format model.AmountFormat) (map[string]string, []validator.Violation, error) {
+	if len(record) != header.Width {
+		return nil, nil, rejectField("", model.ReasonWrongColumnCount, fmt.Errorf("expected %d columns, got %d", header.Width, len(record)))
 	}
 
-	model.BankStatementRecordsMap[bankName] = append(model.BankStatementRecordsMap[bankName], newRecord)
+	// Rules only see the known columns, by their canonical name
+	fields := make(map[string]string, len(header.Columns))
+	for col, i := range header.Columns {
+		fields[col] = record[i]
+	}
 
-	return nil
+	violations, err := validator.ValidateRecord(fields, recordType, format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rejection := validator.FirstRejection(violations); rejection != nil {
+		return nil, nil, rejectField(rejection.Field, rejection.Reason, errors.New(rejection.Message))
+	}
+
+	return fields, violations, nil
+}
+
+// extraFields keeps the values of the unknown columns of a row, it returns nil when the file has none
+func extraFields(record []string, header validator.Header) map[string]string {
+	if len(header.Extra) == 0 {
+		return nil
+	}
+
+	extra := make(map[string]string, len(header.Extra))
+	for col, i := range header.Extra {
+		extra[col] = record[i]
+	}
+	return extra
 }
 
 type MatchIndex struct {
 	Index map[string]map[float64]map[string][]*model.BankStatementRecord
 	Locks map[string]map[float64]map[string]*sync.Mutex
+	// Banks tells which bank each indexed record belongs to
+	Banks map[*model.BankStatementRecord]string
 }
 
 func BuildBankIndex() MatchIndex {
 	idx := MatchIndex{
 		Index: make(map[string]map[float64]map[string][]*model.BankStatementRecord),
 		Locks: make(map[string]map[float64]map[string]*sync.Mutex),
+		Banks: make(map[*model.BankStatementRecord]string),
 	}
 
-	for _, bankRecords := range model.BankStatementRecordsMap {
+	for bankName, bankRecords := range model.BankStatementRecordsMap {
 		for _, rec := range bankRecords {
+			idx.Banks[rec] = bankName
+
 			date, _ := util.ConvertBankStatementDate(rec.Date)
 			amount := math.Abs(rec.Amount)
-			txType := "credit"
-			if rec.Amount < 0 {
-				txType = "debit"
-			}
+			txType := rec.Direction
 
 			if _, ok := idx.Index[date]; !ok {
 				idx.Index[date] = make(map[float64]map[string][]*model.BankStatementRecord)
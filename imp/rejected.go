@@ -0,0 +1,75 @@
+package impl
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/validator"
+)
+
+// recordError is returned by the row parsers, it tells which field was wrong and why
+type recordError struct {
+	field  string
+	reason string
+	err    error
+}
+
+func (e *recordError) Error() string {
+	return e.err.Error()
+}
+
+func (e *recordError) Unwrap() error {
+	return e.err
+}
+
+func rejectField(field string, reason string, err error) error {
+	return &recordError{field: field, reason: reason, err: err}
+}
+
+func newRejectedRecord(filePath string, line int, row []string, err error) model.RejectedRecord {
+	rejected := model.RejectedRecord{
+		SourceFile: filePath,
+		Line:       line,
+		Raw:        rawRow(row),
+		Reason:     model.ReasonMalformedRow,
+		Message:    err.Error(),
+	}
+
+	var recErr *recordError
+	if errors.As(err, &recErr) {
+		rejected.Field = recErr.field
+		rejected.Reason = recErr.reason
+	}
+
+	return rejected
+}
+
+// rawRow writes the row back as a csv line so it can be sent back as it was received
+func rawRow(row []string) string {
+	if row == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	writer.Write(row)
+	writer.Flush()
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func newRecordWarnings(filePath string, line int, violations []validator.Violation) []model.RecordWarning {
+	var warnings []model.RecordWarning
+	for _, violation := range violations {
+		warnings = append(warnings, model.RecordWarning{
+			SourceFile: filePath,
+			Line:       line,
+			Field:      violation.Field,
+			Rule:       violation.Rule,
+			Message:    violation.Message,
+		})
+	}
+	return warnings
+}
@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"github.com/sientong/reconciliation-service/model"
+	validator "github.com/sientong/reconciliation-service/validator"
+)
+
+// ValidateInputs checks the header of every input file, parses all of its rows and checks the data quality
+// thresholds, the way a reconciliation would before matching. A file which cannot be used does not stop
+// the other files from being checked.
+func ValidateInputs(systemFiles []string, bankFiles []string, startDate string, endDate string, thresholds model.DataQualityThresholds) model.Validation {
+	var validation model.Validation
+
+	check := func(filePath string, recordType string, load func() error) {
+		file := model.FileValidation{FilePath: filePath, RecordType: recordType}
+		if err := validator.ValidateFile(filePath, recordType); err != nil {
+			file.Error = err.Error()
+		} else if err := load(); err != nil {
+			file.Error = err.Error()
+		}
+		validation.Files = append(validation.Files, file)
+	}
+
+	for _, systemFile := range systemFiles {
+		check(systemFile, "systemTransaction", func() error {
+			return createSystemTransactionsRecords(systemFile, startDate, endDate)
+		})
+	}
+	for _, bankFile := range bankFiles {
+		check(bankFile, "bankStatement", func() error {
+			return CreateBankStatementRecords([]string{bankFile}, startDate, endDate)
+		})
+	}
+
+	breaches := CheckDataQuality(thresholds)
+
+	for i := range validation.Files {
+		file := &validation.Files[i]
+		if file.Error != "" {
+			continue
+		}
+
+		for j := range model.Run.Ingestion {
+			if model.Run.Ingestion[j].File == file.FilePath {
+				file.Ingestion = &model.Run.Ingestion[j]
+			}
+		}
+		for j := range model.LoadedFiles {
+			if model.LoadedFiles[j].FilePath == file.FilePath {
+				file.Profile = &model.LoadedFiles[j]
+			}
+		}
+		for _, rejected := range model.RejectedRecords {
+			if rejected.SourceFile == file.FilePath {
+				file.Rejected = append(file.Rejected, rejected)
+			}
+		}
+		for _, breach := range breaches {
+			if breach.File == file.FilePath {
+				file.Breaches = append(file.Breaches, breach)
+			}
+		}
+	}
+
+	for _, breach := range breaches {
+		if breach.File == "" {
+			validation.Breaches = append(validation.Breaches, breach)
+		}
+	}
+
+	return validation
+}
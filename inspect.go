@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sientong/reconciliation-service/cli"
+	impl "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/report"
+)
+
+// Date range of inspect when none is given, so every row of the files is looked at
+const (
+	inspectFirstDate = "00010101"
+	inspectLastDate  = "99991231"
+)
+
+// runInspect loads the input files and prints their ingestion decisions, profiles and balance checks
+func runInspect(args []string) int {
+	flags := cli.NewFlagSet("inspect", "[system.csv bank1.csv,bank2.csv [YYYYMMDD YYYYMMDD]]",
+		"Loads the input files and prints their format, row counts, amounts and balance checks, without\n"+
+			"reconciling them. Without a date range every row is loaded.")
+	var in cli.InputFlags
+	var settings settingsFlags
+	in.Register(flags)
+	settings.register(flags)
+	settings.registerInputSettings(flags)
+	format := flags.String("format", "text", "output `format`, text or json")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %s, expected text or json\n", *format)
+		return exitInputFailure
+	}
+	if *format == "json" {
+		impl.LogWriter = os.Stderr
+	}
+
+	if len(flags.Args()) == 0 {
+		in.DefaultRange(inspectFirstDate, inspectLastDate)
+	}
+
+	inputs, err := in.Resolve(flags.Args(), time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	cfg, err := settings.load(flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	if err := applySettings(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	if err := loadInputs(inputs); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	inspection := &model.Output{
+		RejectedRecords: model.RejectedRecords,
+		RecordWarnings:  model.RecordWarnings,
+		FileProfiles:    model.LoadedFiles,
+		BalanceChecks:   model.BalanceChecks,
+		Metadata:        model.Run,
+	}
+
+	if *format == "json" {
+		if err := report.WriteInspectionJSON(os.Stdout, inspection); err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon writing results:", err)
+			return exitInternalError
+		}
+		return exitSuccess
+	}
+
+	report.WriteIngestionDecisions(os.Stdout, model.Run.Ingestion)
+	report.WriteFileProfiles(os.Stdout, model.LoadedFiles)
+	fmt.Println()
+	report.WriteBalanceChecks(os.Stdout, model.BalanceChecks)
+	fmt.Printf("Rejected records: %d\n", len(model.RejectedRecords))
+	for _, rejected := range model.RejectedRecords {
+		fmt.Printf(" - %s:%d [%s] %s\n", rejected.SourceFile, rejected.Line, rejected.Reason, rejected.Message)
+	}
+
+	return exitSuccess
+}
@@ -1,109 +1,92 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
-	"strings"
-	"time"
+	"runtime/debug"
 
+	"github.com/sientong/reconciliation-service/cli"
 	impl "github.com/sientong/reconciliation-service/imp"
 	"github.com/sientong/reconciliation-service/model"
-	"github.com/sientong/reconciliation-service/validator"
 
 	"github.com/joho/godotenv"
 )
 
-func init() {
-	// Initialize the model and other necessary components
-	impl.InitModel()
-	err := godotenv.Load()
-	if err != nil {
-		fmt.Println("Error loading .env file:", err)
-	}
+// Exit statuses of a run, so a scheduler can tell a clean run from a broken one
+const (
+	exitReconciled = 0
+	// exitUnmatched is a run with unmatched items within the discrepancy thresholds
+	exitUnmatched = 1
+	// exitDiscrepancy is a run with unmatched items above a discrepancy threshold
+	exitDiscrepancy = 2
+	// exitInputFailure is returned for invalid arguments, settings or input files
+	exitInputFailure = 3
+	// exitDataQualityFailure is returned when the loaded files cross a data quality threshold
+	exitDataQualityFailure = 4
+	// exitInternalError is returned when the reconciliation or writing its results failed, the matched pairs file
+	// included. A panic, in main or in a worker of the concurrent strategies, is recovered and returned as this
+	// status, so it never exits with the status 2 of the Go runtime, which would read as exitDiscrepancy.
+	exitInternalError = 5
+	// exitSuccess is returned by the commands which do not reconcile when they succeed, and after printing the help
+	exitSuccess = 0
+)
+
+var outcomeExitCodes = map[string]int{
+	model.OutcomeReconciled:  exitReconciled,
+	model.OutcomeUnmatched:   exitUnmatched,
+	model.OutcomeDiscrepancy: exitDiscrepancy,
 }
 
-func main() {
-	var argsRaw = os.Args[1:]
-	if err := validator.ValidateArgs(argsRaw); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		os.Exit(1)
+func commands() []cli.Command {
+	return []cli.Command{
+		{Name: "reconcile", Summary: "reconcile system transactions against bank statements", Run: runReconcile},
+		{Name: "validate", Summary: "check the input files without reconciling them", Run: runValidate},
+		{Name: "inspect", Summary: "load the input files and print what was found in them", Run: runInspect},
+		{Name: "report", Summary: "write stored json results as text, json, csv, html or xlsx", Run: runReport},
+		{Name: "diff", Summary: "compare two stored json results", Run: runDiff},
+		{Name: "generate", Summary: "write a synthetic dataset and the results it is expected to reconcile to", Run: runGenerate},
+		{Name: "config", Summary: "print the effective settings and check them", Run: runConfig},
 	}
+}
 
-	fmt.Println("All arguments are valid. Proceeding with creating records...")
-
-	systemTransactionFile := argsRaw[0]
-	bankStatementFiles := strings.Split(argsRaw[1], ",")
-	startDate := argsRaw[2]
-	endDate := argsRaw[3]
-
-	if err := validator.ValidateFile(systemTransactionFile, "systemTransaction"); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		os.Exit(1)
-	}
+func init() {
+	// Initialize the model and other necessary components
+	impl.InitModel()
 
-	if err := impl.CreateRecords(systemTransactionFile, "systemTransaction", startDate, endDate); err != nil {
-		fmt.Println("Error upon creating transaction records:", err)
+	// .env is optional, its variables are the environment layer of the settings
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "Error loading .env file:", err)
 	}
+}
 
-	for _, bankFile := range bankStatementFiles {
-		if err := validator.ValidateFile(bankFile, "bankStatement"); err != nil {
-			fmt.Fprintln(os.Stderr, "Error:", err)
-			os.Exit(1)
-		}
-
-		if err := impl.CreateRecords(bankFile, "bankStatement", startDate, endDate); err != nil {
-			fmt.Println("Error upon creating bank statement records:", err)
+func main() {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			fmt.Fprintf(os.Stderr, "Internal error: %v\n%s", recovered, debug.Stack())
+			os.Exit(exitInternalError)
 		}
-	}
-
-	fmt.Println("\nAll records created successfully. Starting reconciliation...")
-	start := time.Now()
-
-	reconcilliationStrategy := os.Getenv("RECONCILLIATION_STRATEGY")
-
-	var output *model.Output
-	var err error
-
-	switch reconcilliationStrategy {
-	case "simple":
-		fmt.Println("Using simple reconciliation strategy...")
-		output, err = impl.SimpleReconciliation()
+	}()
 
-	case "concurrent":
-		fmt.Println("Using concurrent reconciliation strategy...")
-		output, err = impl.ConcurrentReconcilliation()
-
-	default:
-		fmt.Printf("Unknown reconciliation strategy '%s', defaulting to 'concurrent'\n", reconcilliationStrategy)
-		output, err = impl.ConcurrentReconcilliation()
-	}
-
-	if err != nil {
-		fmt.Println("Error upon reconciliation:", err)
-	}
+	os.Exit(run(os.Args[1:]))
+}
 
-	fmt.Println("\n---- Reconciliation results: ----")
-	if output == nil {
-		fmt.Println("No records to display.")
-	} else {
-		fmt.Printf("Total processed records: %d\n", output.TotalProcessedRecords)
-		fmt.Printf("Total matched transactions: %d\n", output.TotalMatchedTransactions)
-		fmt.Printf("Total unmatched transactions: %d\n", output.TotalUnmatchedTransactions)
-		fmt.Printf("Total invalid records: %d\n", output.TotalInvalidRecords)
-		fmt.Printf("Total discrepancies: %.2f\n\n", output.TotalDiscrepancies)
-		fmt.Printf("Unmatched system transactions: %d\n", output.TotalUnmatchedSystemTransactions)
-		for _, trx := range output.UnmatchedSystemTransactions {
-			fmt.Printf(" - %s: %.2f on %s\n", trx.TrxID, trx.Amount, trx.TransactionTime)
-		}
-		fmt.Printf("Unmatched bank statements: %d\n", output.TotalUnmatchedBankStmts)
-		for bankName, stmts := range output.UnmatchedBankStmts {
-			fmt.Printf(" + %s\n", bankName)
-			for _, stmt := range stmts {
-				fmt.Printf("   - %s: %.2f on %s\n", stmt.UniqueIdentifier, stmt.Amount, stmt.Date)
-			}
-		}
+func run(args []string) int {
+	cmd, cmdArgs, err := cli.Dispatch(commands(), "reconcile", args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		cli.Usage(os.Stdout, commands())
+		return exitSuccess
+	case errors.Is(err, cli.ErrNoCommand):
+		cli.Usage(os.Stderr, commands())
+		return exitInputFailure
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		cli.Usage(os.Stderr, commands())
+		return exitInputFailure
 	}
 
-	duration := time.Since(start)
-	fmt.Printf("\nReconciliation completed in %s\n", duration)
+	return cmd.Run(cmdArgs)
 }
@@ -0,0 +1,46 @@
+package model
+
+// Sides of an unmatched item
+const (
+	SideSystem = "system"
+	SideBank   = "bank"
+)
+
+// AgingBucket is a range of ages in days, MaxDays is negative for the open ended last bucket
+type AgingBucket struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"min_days"`
+	MaxDays int    `json:"max_days"`
+}
+
+// AgedItem is an unmatched system transaction or bank statement with its age on the reference date
+type AgedItem struct {
+	Side       string  `json:"side"`
+	BankName   string  `json:"bank_name,omitempty"`
+	Identifier string  `json:"identifier"`
+	Amount     float64 `json:"amount"`
+	Date       string  `json:"date"`
+	AgeDays    int     `json:"age_days"`
+	Bucket     string  `json:"bucket"`
+}
+
+// AgingTotals are the count and the sum of the absolute amounts of unmatched items
+type AgingTotals struct {
+	Count  int     `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+// AgingBucketSummary are the unmatched items of a bucket, in total, on the system side and per bank
+type AgingBucketSummary struct {
+	AgingBucket
+	AgingTotals
+	System AgingTotals             `json:"system"`
+	ByBank map[string]*AgingTotals `json:"by_bank"`
+}
+
+// Aging groups the unmatched items by age, counted in days before the reference date
+type Aging struct {
+	ReferenceDate string               `json:"reference_date"`
+	Items         []AgedItem           `json:"items"`
+	Buckets       []AgingBucketSummary `json:"buckets"`
+}
@@ -0,0 +1,39 @@
+package model
+
+// Identifiers of the inline balance rows of a bank statement
+const (
+	OpeningBalanceIdentifier = "OPENING_BALANCE"
+	ClosingBalanceIdentifier = "CLOSING_BALANCE"
+)
+
+// Balance check statuses
+const (
+	BalanceBalanced    = "balanced"
+	BalanceBreak       = "break"
+	BalanceIncomplete  = "incomplete"
+	BalanceNotSupplied = "not_supplied"
+)
+
+// StatementBalance is the opening and closing balance of a bank statement, a nil balance was not supplied
+type StatementBalance struct {
+	Opening *float64
+	Closing *float64
+}
+
+// BalanceCheck verifies that opening + sum of the statement lines = closing for a bank statement file.
+// A positive difference means lines are missing from the statement, a negative one means it has extra lines.
+type BalanceCheck struct {
+	File       string   `json:"file"`
+	BankName   string   `json:"bank_name"`
+	Opening    *float64 `json:"opening"`
+	Closing    *float64 `json:"closing"`
+	LinesTotal float64  `json:"lines_total"`
+	Difference float64  `json:"difference"`
+	Status     string   `json:"status"`
+	Message    string   `json:"message"`
+}
+
+// BankBalances are the balances supplied in a side file, keyed by the bank statement file name
+var BankBalances = make(map[string]StatementBalance)
+
+var BalanceChecks []BalanceCheck
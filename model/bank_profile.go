@@ -0,0 +1,18 @@
+package model
+
+// AmountFormat describes how a bank writes amounts in its exports.
+// An empty DecimalSeparator means the separators are inferred from the value itself.
+type AmountFormat struct {
+	Name              string
+	DecimalSeparator  string
+	ThousandSeparator string
+	CurrencySymbols   []string
+}
+
+type BankProfile struct {
+	Name         string
+	AmountFormat AmountFormat
+}
+
+// BankProfiles holds the per bank settings, keyed by the bank name taken from the file name
+var BankProfiles = make(map[string]BankProfile)
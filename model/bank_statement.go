@@ -1,10 +1,18 @@
 package model
 
+const (
+	DirectionCredit = "credit"
+	DirectionDebit  = "debit"
+)
+
 type BankStatementRecord struct {
-	UniqueIdentifier string
-	Amount           float64
-	Date             string
-	IsMatched        bool
+	UniqueIdentifier string  `json:"unique_identifier"`
+	Amount           float64 `json:"amount"`
+	Direction        string  `json:"direction"`
+	Date             string  `json:"date"`
+	IsMatched        bool    `json:"is_matched"`
+	// Extra are the values of the columns the service does not know, keyed by column name
+	Extra map[string]string `json:"extra,omitempty"`
 }
 
 var BankStatementRecordsMap = make(map[string][]*BankStatementRecord)
@@ -0,0 +1,27 @@
+package model
+
+// Breakdown are the reconciliation statistics of a bank, a calendar day, or a bank on a day.
+// Processed counts the records of both sides, the sums are of absolute amounts.
+type Breakdown struct {
+	Processed       int     `json:"processed"`
+	Matched         int     `json:"matched"`
+	UnmatchedSystem int     `json:"unmatched_system"`
+	UnmatchedBank   int     `json:"unmatched_bank"`
+	MatchRate       float64 `json:"match_rate"`
+	SystemCredits   float64 `json:"system_credits"`
+	SystemDebits    float64 `json:"system_debits"`
+	BankCredits     float64 `json:"bank_credits"`
+	BankDebits      float64 `json:"bank_debits"`
+}
+
+// Add adds the counts and sums of other, the match rate is computed again afterwards
+func (b *Breakdown) Add(other *Breakdown) {
+	b.Processed += other.Processed
+	b.Matched += other.Matched
+	b.UnmatchedSystem += other.UnmatchedSystem
+	b.UnmatchedBank += other.UnmatchedBank
+	b.SystemCredits += other.SystemCredits
+	b.SystemDebits += other.SystemDebits
+	b.BankCredits += other.BankCredits
+	b.BankDebits += other.BankDebits
+}
@@ -0,0 +1,22 @@
+package model
+
+// QualityThresholds are the limits an input must respect, a nil limit is not checked
+type QualityThresholds struct {
+	MaxRejectedPct   *float64 `yaml:"max_rejected_pct"`
+	MinRowCount      *int     `yaml:"min_rows"`
+	MaxOutOfRangePct *float64 `yaml:"max_out_of_range_pct"`
+}
+
+type DataQualityThresholds struct {
+	PerFile QualityThresholds `yaml:"per_file"`
+	Global  QualityThresholds `yaml:"global"`
+}
+
+// QualityBreach is a threshold crossed by a file, or by all files or the whole run when File is empty
+type QualityBreach struct {
+	File      string  `json:"file,omitempty"`
+	Threshold string  `json:"threshold"`
+	Limit     float64 `json:"limit"`
+	Actual    float64 `json:"actual"`
+	Message   string  `json:"message"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// Encodings detected for input files
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// Dialect is how an input csv file is written
+type Dialect struct {
+	Encoding  string `json:"encoding"`
+	BOM       bool   `json:"bom"`
+	Delimiter string `json:"delimiter"`
+	// Quoted is set when the header uses quoted fields
+	Quoted bool `json:"quoted"`
+}
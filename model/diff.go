@@ -0,0 +1,48 @@
+package model
+
+// DiffItem is a system transaction or a bank statement whose status changed between two runs.
+// Pair is its matched pair in the newer run, when there is one.
+type DiffItem struct {
+	Side       string       `json:"side"`
+	BankName   string       `json:"bank_name,omitempty"`
+	Identifier string       `json:"identifier"`
+	Amount     float64      `json:"amount"`
+	Date       string       `json:"date"`
+	Pair       *MatchedPair `json:"pair,omitempty"`
+}
+
+// RepairedItem is a system transaction matched in both runs, but to another bank statement
+type RepairedItem struct {
+	TrxID    string      `json:"trx_id"`
+	Previous MatchedPair `json:"previous"`
+	Current  MatchedPair `json:"current"`
+}
+
+// TotalChange is a total of the output which changed between two runs
+type TotalChange struct {
+	Name     string  `json:"name"`
+	Previous float64 `json:"previous"`
+	Current  float64 `json:"current"`
+	Delta    float64 `json:"delta"`
+}
+
+// ResultDiff is what changed from a previous reconciliation result to the current one. System transactions
+// are identified by TrxID and bank statements by bank name and UniqueIdentifier.
+type ResultDiff struct {
+	Previous       string     `json:"previous"`
+	Current        string     `json:"current"`
+	NewlyMatched   []DiffItem `json:"newly_matched"`
+	NewlyUnmatched []DiffItem `json:"newly_unmatched"`
+	// Removed were unmatched in the previous run and are not in the current one, e.g. when a file was left out
+	Removed         []DiffItem       `json:"removed"`
+	Repaired        []RepairedItem   `json:"repaired"`
+	RejectedAdded   []RejectedRecord `json:"rejected_added"`
+	RejectedRemoved []RejectedRecord `json:"rejected_removed"`
+	Totals          []TotalChange    `json:"totals"`
+}
+
+// Empty tells whether the two runs have the same results
+func (d ResultDiff) Empty() bool {
+	return len(d.NewlyMatched) == 0 && len(d.NewlyUnmatched) == 0 && len(d.Removed) == 0 && len(d.Repaired) == 0 &&
+		len(d.RejectedAdded) == 0 && len(d.RejectedRemoved) == 0 && len(d.Totals) == 0
+}
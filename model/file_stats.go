@@ -0,0 +1,38 @@
+package model
+
+// FileStats counts what happened to the rows of an input file while loading it
+type FileStats struct {
+	FilePath     string `json:"file_path"`
+	RecordType   string `json:"record_type"`
+	BankName     string `json:"bank_name,omitempty"`
+	TotalRows    int    `json:"total_rows"`
+	AcceptedRows int    `json:"accepted_rows"`
+	// RejectedRows are the rows rejected for any reason except being outside the date range
+	RejectedRows   int `json:"rejected_rows"`
+	OutOfRangeRows int `json:"out_of_range_rows"`
+	// DuplicateRows are valid rows dropped because the same row was already loaded from another file
+	DuplicateRows int `json:"duplicate_rows"`
+}
+
+// ProfiledAmount is one of the largest amounts of a file
+type ProfiledAmount struct {
+	Identifier string  `json:"identifier"`
+	Amount     float64 `json:"amount"`
+	Date       string  `json:"date"`
+}
+
+// FileProfile describes what was loaded from an input file. Dates and sums only cover the accepted and duplicate rows,
+// debits are summed as positive amounts.
+type FileProfile struct {
+	FileStats
+	Dialect          Dialect          `json:"dialect"`
+	RejectedByReason map[string]int   `json:"rejected_by_reason"`
+	MinDate          string           `json:"min_date"`
+	MaxDate          string           `json:"max_date"`
+	SumCredits       float64          `json:"sum_credits"`
+	SumDebits        float64          `json:"sum_debits"`
+	CountPerDay      map[string]int   `json:"count_per_day"`
+	LargestAmounts   []ProfiledAmount `json:"largest_amounts"`
+}
+
+var LoadedFiles []FileProfile
@@ -0,0 +1,42 @@
+package model
+
+// Ingestion decisions taken for an input file
+const (
+	IngestionLoaded       = "loaded"
+	IngestionDeduplicated = "deduplicated"
+	IngestionRefused      = "refused"
+)
+
+// FileFingerprint identifies an input file by the hash of its content
+type FileFingerprint struct {
+	File        string `json:"file"`
+	RecordType  string `json:"record_type"`
+	BankName    string `json:"bank_name,omitempty"`
+	ContentHash string `json:"content_hash"`
+	LoadedAt    string `json:"loaded_at"`
+}
+
+// IngestionDecision tells whether an input file was loaded, loaded without the rows seen before,
+// or refused because the same content was already loaded
+type IngestionDecision struct {
+	FileFingerprint
+	Decision      string `json:"decision"`
+	DuplicateOf   string `json:"duplicate_of,omitempty"`
+	DuplicateRows int    `json:"duplicate_rows"`
+	Message       string `json:"message"`
+}
+
+// RunMetadata describes a reconciliation run
+type RunMetadata struct {
+	StartedAt string              `json:"started_at"`
+	Ingestion []IngestionDecision `json:"ingestion"`
+}
+
+var Run RunMetadata
+
+// FileFingerprints are the files loaded in this run, and in previous runs when they are persisted, keyed by content hash
+var FileFingerprints = make(map[string]FileFingerprint)
+
+// RowFingerprints are the hashes of the rows already loaded, per record source
+// ("systemTransaction" or the bank name)
+var RowFingerprints = make(map[string]map[string]struct{})
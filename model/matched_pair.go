@@ -0,0 +1,18 @@
+package model
+
+// Match rules, telling why a system transaction and a bank statement were matched
+const (
+	// MatchRuleExact matches the same amount, date and direction
+	MatchRuleExact = "exact_amount_date_direction"
+)
+
+// MatchedPair is a system transaction and the bank statement it was matched to.
+// The deltas are bank minus system: days between the dates and the difference of the absolute amounts.
+type MatchedPair struct {
+	System        InternalTransactionRecord `json:"system"`
+	BankName      string                    `json:"bank_name"`
+	Bank          BankStatementRecord       `json:"bank"`
+	Rule          string                    `json:"rule"`
+	DateDeltaDays int                       `json:"date_delta_days"`
+	AmountDelta   float64                   `json:"amount_delta"`
+}
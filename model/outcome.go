@@ -0,0 +1,16 @@
+package model
+
+// Outcomes of a reconciliation run
+const (
+	OutcomeReconciled  = "reconciled"
+	OutcomeUnmatched   = "unmatched"
+	OutcomeDiscrepancy = "discrepancy"
+)
+
+// DiscrepancyThresholds tell when the unmatched items of a run are more than the usual follow-ups,
+// a nil limit is not checked
+type DiscrepancyThresholds struct {
+	MaxAmount       *float64 `yaml:"max_amount"`
+	MaxUnmatched    *int     `yaml:"max_unmatched"`
+	MaxUnmatchedPct *float64 `yaml:"max_unmatched_pct"`
+}
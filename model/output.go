@@ -1,13 +1,26 @@
 package model
 
 type Output struct {
-	TotalProcessedRecords            int
-	TotalMatchedTransactions         int
-	TotalUnmatchedTransactions       int
-	TotalUnmatchedSystemTransactions int
-	TotalUnmatchedBankStmts          int
-	TotalInvalidRecords              int
-	TotalDiscrepancies               float64
-	UnmatchedSystemTransactions      []InternalTransactionRecord
-	UnmatchedBankStmts               map[string][]BankStatementRecord
+	Outcome                          string                           `json:"outcome"`
+	DiscrepancyBreaches              []QualityBreach                  `json:"discrepancy_breaches"`
+	TotalProcessedRecords            int                              `json:"total_processed_records"`
+	TotalMatchedTransactions         int                              `json:"total_matched_transactions"`
+	TotalUnmatchedTransactions       int                              `json:"total_unmatched_transactions"`
+	TotalUnmatchedSystemTransactions int                              `json:"total_unmatched_system_transactions"`
+	TotalUnmatchedBankStmts          int                              `json:"total_unmatched_bank_stmts"`
+	TotalInvalidRecords              int                              `json:"total_invalid_records"`
+	TotalDiscrepancies               float64                          `json:"total_discrepancies"`
+	MatchedPairs                     []MatchedPair                    `json:"matched_pairs"`
+	MatchedPairsFile                 string                           `json:"matched_pairs_file,omitempty"`
+	UnmatchedSystemTransactions      []InternalTransactionRecord      `json:"unmatched_system_transactions"`
+	UnmatchedBankStmts               map[string][]BankStatementRecord `json:"unmatched_bank_stmts"`
+	RejectedRecords                  []RejectedRecord                 `json:"rejected_records"`
+	RecordWarnings                   []RecordWarning                  `json:"record_warnings"`
+	FileProfiles                     []FileProfile                    `json:"file_profiles"`
+	BalanceChecks                    []BalanceCheck                   `json:"balance_checks"`
+	ByBank                           map[string]*Breakdown            `json:"by_bank"`
+	ByDay                            map[string]*Breakdown            `json:"by_day"`
+	ByBankDay                        map[string]map[string]*Breakdown `json:"by_bank_day"`
+	Aging                            Aging                            `json:"aging"`
+	Metadata                         RunMetadata                      `json:"metadata"`
 }
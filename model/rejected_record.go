@@ -0,0 +1,36 @@
+package model
+
+// Reason codes of rejected records
+const (
+	ReasonBadAmount        = "bad_amount"
+	ReasonBadDate          = "bad_date"
+	ReasonOutOfRange       = "out_of_range"
+	ReasonBadType          = "bad_type"
+	ReasonWrongColumnCount = "wrong_column_count"
+	ReasonMalformedRow     = "malformed_row"
+	ReasonMissingField     = "missing_field"
+	ReasonBadFormat        = "bad_format"
+)
+
+// RejectedRecord is an input row that could not be turned into a record
+type RejectedRecord struct {
+	SourceFile string `json:"source_file"`
+	Line       int    `json:"line"`
+	Raw        string `json:"raw"`
+	Field      string `json:"field"`
+	Reason     string `json:"reason"`
+	Message    string `json:"message"`
+}
+
+var RejectedRecords []RejectedRecord
+
+// RecordWarning is a rule with warn severity that did not pass, the record itself is still loaded
+type RecordWarning struct {
+	SourceFile string `json:"source_file"`
+	Line       int    `json:"line"`
+	Field      string `json:"field"`
+	Rule       string `json:"rule"`
+	Message    string `json:"message"`
+}
+
+var RecordWarnings []RecordWarning
@@ -1,11 +1,13 @@
 package model
 
 type InternalTransactionRecord struct {
-	TrxID           string
-	Amount          float64
-	Type            string
-	TransactionTime string
-	IsMatched       bool
+	TrxID           string  `json:"trx_id"`
+	Amount          float64 `json:"amount"`
+	Type            string  `json:"type"`
+	TransactionTime string  `json:"transaction_time"`
+	IsMatched       bool    `json:"is_matched"`
+	// Extra are the values of the columns the service does not know, keyed by column name
+	Extra map[string]string `json:"extra,omitempty"`
 }
 
 var SystemTransactionRecords []*InternalTransactionRecord
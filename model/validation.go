@@ -0,0 +1,43 @@
+package model
+
+// FileValidation is what validate found in an input file
+type FileValidation struct {
+	FilePath   string `json:"file_path"`
+	RecordType string `json:"record_type"`
+	// Error is why the file cannot be used, such as a missing file or a wrong header
+	Error     string             `json:"error,omitempty"`
+	Ingestion *IngestionDecision `json:"ingestion,omitempty"`
+	Profile   *FileProfile       `json:"profile,omitempty"`
+	Rejected  []RejectedRecord   `json:"rejected,omitempty"`
+	Breaches  []QualityBreach    `json:"breaches,omitempty"`
+}
+
+// Validation is the result of checking the input files without reconciling them
+type Validation struct {
+	Files []FileValidation `json:"files"`
+	// Breaches are the data quality thresholds crossed by all files together
+	Breaches []QualityBreach `json:"breaches,omitempty"`
+}
+
+// Unusable tells whether an input file cannot be loaded
+func (v Validation) Unusable() bool {
+	for _, file := range v.Files {
+		if file.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// QualityFailed tells whether a data quality threshold was crossed
+func (v Validation) QualityFailed() bool {
+	if len(v.Breaches) > 0 {
+		return true
+	}
+	for _, file := range v.Files {
+		if len(file.Breaches) > 0 {
+			return true
+		}
+	}
+	return false
+}
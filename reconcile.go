@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sientong/reconciliation-service/cli"
+	impl "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/report"
+)
+
+// strategies are the reconciliation strategies, by the names of config.Strategies
+var strategies = map[string]func() (*model.Output, error){
+	"simple":     impl.SimpleReconciliation,
+	"concurrent": impl.ConcurrentReconcilliation,
+	"indexed":    impl.ConcurrentReconciliationIndexed,
+}
+
+// runReconcile loads the input files, reconciles them and writes the results, it returns the exit status
+func runReconcile(args []string) int {
+	flags := cli.NewFlagSet("reconcile", "[system.csv bank1.csv,bank2.csv [YYYYMMDD YYYYMMDD]]",
+		"Reconciles system transactions against bank statements. The input files and the date range are given\n"+
+			"with flags, or as positional arguments. Without a date range given, the range goes from the\n"+
+			"first to the last statement date of the bank file names.")
+	var in cli.InputFlags
+	var out outputFlags
+	var settings settingsFlags
+	in.Register(flags)
+	out.register(flags)
+	settings.register(flags)
+	settings.registerInputSettings(flags)
+	strategy := flags.String("strategy", "", "reconciliation `strategy`: simple, concurrent or indexed, concurrent by default")
+	workers := flags.Int("workers", 0, "`number` of workers of the concurrent strategies, twice the number of CPUs by default")
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	cfg, err := settings.load(flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+	out.merge(flags, cfg)
+	if cli.FlagGiven(flags, "strategy") {
+		cfg.Strategy = *strategy
+	}
+	if cli.FlagGiven(flags, "workers") {
+		cfg.Workers = *workers
+	}
+
+	logs := out.logs()
+	impl.LogWriter = logs
+
+	inputs, err := in.Resolve(flags.Args(), time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	if err := applySettings(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	// Unmatched items are aged on the end date unless another reference date is set
+	agingReferenceDate := inputs.End
+	if cfg.Aging.ReferenceDate != "" {
+		agingReferenceDate = cfg.Aging.ReferenceDate
+	}
+
+	if cfg.FingerprintStore != "" {
+		if err := impl.LoadFingerprintStore(cfg.FingerprintStore); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return exitInputFailure
+		}
+	}
+
+	fmt.Fprintf(logs, "Date range %s to %s, from %s\n", inputs.Start, inputs.End, inputs.RangeSource)
+	fmt.Fprintln(logs, "All arguments are valid. Proceeding with creating records...")
+
+	if err := loadInputs(inputs); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	report.WriteIngestionDecisions(logs, model.Run.Ingestion)
+
+	fmt.Fprintln(logs)
+	report.WriteFileProfiles(logs, model.LoadedFiles)
+
+	fmt.Fprintln(logs)
+	report.WriteBalanceChecks(logs, model.BalanceChecks)
+
+	if breaches := impl.CheckDataQuality(cfg.DataQuality); len(breaches) > 0 {
+		fmt.Fprintln(os.Stderr, "Data quality check failed, reconciliation is not started:")
+		for _, breach := range breaches {
+			fmt.Fprintf(os.Stderr, " - [%s] %s\n", breach.Threshold, breach.Message)
+		}
+		return exitDataQualityFailure
+	}
+
+	fmt.Fprintln(logs, "\nAll records created successfully. Starting reconciliation...")
+	start := time.Now()
+
+	matchedPairsFile := cfg.Output.MatchedPairsFile
+	var matchedPairs *report.MatchedPairsFile
+	if matchedPairsFile != "" {
+		matchedPairs, err = report.CreateMatchedPairsFile(matchedPairsFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return exitInternalError
+		}
+		impl.MatchedPairOutput = matchedPairs
+	}
+
+	fmt.Fprintf(logs, "Using %s reconciliation strategy...\n", cfg.Strategy)
+	output, err := strategies[cfg.Strategy]()
+
+	// Failures past this point still write what they can, the exit status tells about them
+	internalError := false
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error upon reconciliation:", err)
+		internalError = true
+	}
+
+	if output != nil {
+		if err := impl.AgeUnmatched(output, agingReferenceDate); err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon aging unmatched items:", err)
+			internalError = true
+		}
+		impl.SetOutcome(output, cfg.Discrepancy)
+	}
+
+	if matchedPairs != nil {
+		if err := matchedPairs.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon writing matched pairs:", err)
+			internalError = true
+		} else if output != nil {
+			output.MatchedPairsFile = matchedPairsFile
+		}
+	}
+
+	if output == nil {
+		report.WriteText(os.Stdout, output)
+		return exitInternalError
+	}
+
+	if !out.write(output, logs) {
+		internalError = true
+	}
+
+	if rejectedFile := cfg.Output.RejectedRecordsFile; rejectedFile != "" {
+		if err := report.WriteRejectedRecordsCSV(rejectedFile, output.RejectedRecords); err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon writing rejected records:", err)
+			internalError = true
+		} else {
+			fmt.Fprintln(logs, "Rejected records written to", rejectedFile)
+		}
+	}
+
+	if cfg.FingerprintStore != "" {
+		if err := impl.SaveFingerprintStore(cfg.FingerprintStore); err != nil {
+			fmt.Fprintln(os.Stderr, "Error upon saving file fingerprints:", err)
+			internalError = true
+		}
+	}
+
+	duration := time.Since(start)
+	fmt.Fprintf(logs, "\nReconciliation completed in %s\n", duration)
+
+	if internalError {
+		return exitInternalError
+	}
+
+	if len(output.DiscrepancyBreaches) > 0 {
+		fmt.Fprintln(os.Stderr, "Discrepancy threshold crossed:")
+		for _, breach := range output.DiscrepancyBreaches {
+			fmt.Fprintf(os.Stderr, " - [%s] %s\n", breach.Threshold, breach.Message)
+		}
+	}
+
+	return outcomeExitCodes[output.Outcome]
+}
@@ -0,0 +1,50 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// WriteAging prints the count and the amount of the unmatched items per age bucket, in total,
+// on the system side and per bank
+func WriteAging(w io.Writer, aging model.Aging) {
+	if len(aging.Buckets) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Aging of unmatched items on %s:\n", aging.ReferenceDate)
+
+	bankNames := agingBankNames(aging)
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(table, " days\tcount\tamount\tsystem\t%s\t\n", strings.Join(bankNames, "\t"))
+	for _, bucket := range aging.Buckets {
+		fmt.Fprintf(table, " %s\t%d\t%s\t%s\t", bucket.Label, bucket.Count, FormatAmount(bucket.Amount), formatAgingTotals(&bucket.System))
+		for _, bankName := range bankNames {
+			fmt.Fprintf(table, "%s\t", formatAgingTotals(bucket.ByBank[bankName]))
+		}
+		fmt.Fprintln(table)
+	}
+	table.Flush()
+}
+
+func formatAgingTotals(totals *model.AgingTotals) string {
+	if totals == nil || totals.Count == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d (%s)", totals.Count, FormatAmount(totals.Amount))
+}
+
+// agingBankNames are the banks with unmatched items in any bucket
+func agingBankNames(aging model.Aging) []string {
+	banks := make(map[string]bool)
+	for _, bucket := range aging.Buckets {
+		for bankName := range bucket.ByBank {
+			banks[bankName] = true
+		}
+	}
+	return sortedKeys(banks)
+}
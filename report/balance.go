@@ -0,0 +1,34 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// WriteBalanceChecks prints the balance control totals per bank, statements without balances are only counted
+func WriteBalanceChecks(w io.Writer, checks []model.BalanceCheck) {
+	notSupplied := 0
+	breaks := 0
+	for _, check := range checks {
+		if check.Status == model.BalanceNotSupplied {
+			notSupplied++
+		}
+		if check.Status == model.BalanceBreak {
+			breaks++
+		}
+	}
+
+	fmt.Fprintf(w, "Balance breaks: %d\n", breaks)
+	for _, check := range checks {
+		if check.Status == model.BalanceNotSupplied {
+			continue
+		}
+		fmt.Fprintf(w, " + %s (%s): %s, %s\n", check.BankName, check.File, check.Status, check.Message)
+	}
+
+	if notSupplied > 0 {
+		fmt.Fprintf(w, " %d bank statement(s) without opening and closing balance\n", notSupplied)
+	}
+}
@@ -0,0 +1,51 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// WriteBreakdowns prints the statistics per bank, per day and per bank and day as aligned tables
+func WriteBreakdowns(w io.Writer, output *model.Output) {
+	if len(output.ByDay) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "By bank:")
+	writeBreakdownTable(w, "bank", output.ByBank)
+
+	fmt.Fprintln(w, "By day:")
+	writeBreakdownTable(w, "day", output.ByDay)
+
+	fmt.Fprintln(w, "By bank and day:")
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	writeBreakdownHeader(table, "bank\tday")
+	for _, bankName := range sortedKeys(output.ByBankDay) {
+		for _, day := range sortedKeys(output.ByBankDay[bankName]) {
+			writeBreakdownRow(table, bankName+"\t"+day, output.ByBankDay[bankName][day])
+		}
+	}
+	table.Flush()
+}
+
+func writeBreakdownTable(w io.Writer, key string, breakdowns map[string]*model.Breakdown) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	writeBreakdownHeader(table, key)
+	for _, name := range sortedKeys(breakdowns) {
+		writeBreakdownRow(table, name, breakdowns[name])
+	}
+	table.Flush()
+}
+
+func writeBreakdownHeader(table *tabwriter.Writer, key string) {
+	fmt.Fprintf(table, " %s\tprocessed\tmatched\tunmatched system\tunmatched bank\tmatch rate\tsystem credits\tsystem debits\tbank credits\tbank debits\t\n", key)
+}
+
+func writeBreakdownRow(table *tabwriter.Writer, key string, breakdown *model.Breakdown) {
+	fmt.Fprintf(table, " %s\t%d\t%d\t%d\t%d\t%.1f%%\t%s\t%s\t%s\t%s\t\n",
+		key, breakdown.Processed, breakdown.Matched, breakdown.UnmatchedSystem, breakdown.UnmatchedBank, breakdown.MatchRate*100,
+		FormatAmount(breakdown.SystemCredits), FormatAmount(breakdown.SystemDebits), FormatAmount(breakdown.BankCredits), FormatAmount(breakdown.BankDebits))
+}
@@ -0,0 +1,113 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// WriteDiff prints what changed between two reconciliation results
+func WriteDiff(w io.Writer, diff model.ResultDiff) {
+	fmt.Fprintf(w, "Changes from %s to %s\n", diff.Previous, diff.Current)
+	if diff.Empty() {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+
+	fmt.Fprintf(w, "Newly matched: %d\n", len(diff.NewlyMatched))
+	for _, item := range diff.NewlyMatched {
+		fmt.Fprintf(w, " - %s", formatDiffItem(item))
+		if item.Pair != nil {
+			if item.Side == model.SideSystem {
+				fmt.Fprintf(w, " = %s %s", item.Pair.BankName, item.Pair.Bank.UniqueIdentifier)
+			} else {
+				fmt.Fprintf(w, " = %s", item.Pair.System.TrxID)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "Newly unmatched: %d\n", len(diff.NewlyUnmatched))
+	for _, item := range diff.NewlyUnmatched {
+		fmt.Fprintf(w, " - %s\n", formatDiffItem(item))
+	}
+
+	fmt.Fprintf(w, "No longer present: %d\n", len(diff.Removed))
+	for _, item := range diff.Removed {
+		fmt.Fprintf(w, " - %s\n", formatDiffItem(item))
+	}
+
+	fmt.Fprintf(w, "Re-paired: %d\n", len(diff.Repaired))
+	for _, repaired := range diff.Repaired {
+		fmt.Fprintf(w, " - %s: %s %s -> %s %s\n", repaired.TrxID,
+			repaired.Previous.BankName, repaired.Previous.Bank.UniqueIdentifier, repaired.Current.BankName, repaired.Current.Bank.UniqueIdentifier)
+	}
+
+	fmt.Fprintf(w, "Rejected rows added: %d\n", len(diff.RejectedAdded))
+	for _, rejected := range diff.RejectedAdded {
+		fmt.Fprintf(w, " + %s:%d [%s] %s\n", rejected.SourceFile, rejected.Line, rejected.Reason, rejected.Message)
+	}
+	fmt.Fprintf(w, "Rejected rows removed: %d\n", len(diff.RejectedRemoved))
+	for _, rejected := range diff.RejectedRemoved {
+		fmt.Fprintf(w, " - %s:%d [%s] %s\n", rejected.SourceFile, rejected.Line, rejected.Reason, rejected.Message)
+	}
+
+	fmt.Fprintf(w, "Totals changed: %d\n", len(diff.Totals))
+	for _, total := range diff.Totals {
+		fmt.Fprintf(w, " - %s: %s -> %s (%s)\n", total.Name, formatTotal(total.Name, total.Previous, false), formatTotal(total.Name, total.Current, false), formatTotal(total.Name, total.Delta, true))
+	}
+}
+
+func formatDiffItem(item model.DiffItem) string {
+	if item.Side == model.SideSystem {
+		return fmt.Sprintf("%s: %s on %s", item.Identifier, FormatAmount(item.Amount), item.Date)
+	}
+	return fmt.Sprintf("%s %s: %s on %s", item.BankName, item.Identifier, FormatAmount(item.Amount), item.Date)
+}
+
+// formatTotal writes counts without decimals and the discrepancies as an amount
+func formatTotal(name string, value float64, signed bool) string {
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	if name == "total_discrepancies" {
+		formatted = FormatAmount(value)
+	}
+	if signed && value > 0 {
+		return "+" + formatted
+	}
+	return formatted
+}
+
+// WriteDiffJSON writes the changes as a json document, lists which are empty are written as []
+func WriteDiffJSON(w io.Writer, diff model.ResultDiff) error {
+	if diff.NewlyMatched == nil {
+		diff.NewlyMatched = []model.DiffItem{}
+	}
+	if diff.NewlyUnmatched == nil {
+		diff.NewlyUnmatched = []model.DiffItem{}
+	}
+	if diff.Removed == nil {
+		diff.Removed = []model.DiffItem{}
+	}
+	if diff.Repaired == nil {
+		diff.Repaired = []model.RepairedItem{}
+	}
+	if diff.RejectedAdded == nil {
+		diff.RejectedAdded = []model.RejectedRecord{}
+	}
+	if diff.RejectedRemoved == nil {
+		diff.RejectedRemoved = []model.RejectedRecord{}
+	}
+	if diff.Totals == nil {
+		diff.Totals = []model.TotalChange{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(diff); err != nil {
+		return fmt.Errorf("encode diff: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// File names of the csv export
+const (
+	UnmatchedSystemTransactionsCSV = "unmatched_system_transactions.csv"
+	UnmatchedBankStatementsCSV     = "unmatched_bank_statements.csv"
+	MatchedPairsCSV                = "matched_pairs.csv"
+	RejectedRecordsCSV             = "rejected_records.csv"
+)
+
+var unmatchedSystemTransactionsHeader = []string{"trx_id", "amount", "type", "transaction_time"}
+var unmatchedBankStatementsHeader = []string{"bank", "unique_identifier", "amount", "direction", "date"}
+var matchedPairsHeader = []string{
+	"trx_id", "system_amount", "type", "transaction_time",
+	"bank", "unique_identifier", "bank_amount", "direction", "date",
+	"rule", "date_delta_days", "amount_delta",
+}
+
+// ExportCSV writes the unmatched system transactions, the unmatched bank statements, the matched pairs and
+// the rejected rows of the output to separate csv files in dir, and returns the paths of the files written
+func ExportCSV(dir string, output *model.Output) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create %s: %w", dir, err)
+	}
+
+	var written []string
+
+	filePath := filepath.Join(dir, UnmatchedSystemTransactionsCSV)
+	err := writeCSV(filePath, unmatchedSystemTransactionsHeader, func(write func([]string) error) error {
+		for _, trx := range output.UnmatchedSystemTransactions {
+			if err := write([]string{trx.TrxID, FormatAmount(trx.Amount), trx.Type, trx.TransactionTime}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return written, err
+	}
+	written = append(written, filePath)
+
+	filePath = filepath.Join(dir, UnmatchedBankStatementsCSV)
+	err = writeCSV(filePath, unmatchedBankStatementsHeader, func(write func([]string) error) error {
+		for _, bankName := range sortedKeys(output.UnmatchedBankStmts) {
+			for _, stmt := range output.UnmatchedBankStmts[bankName] {
+				if err := write([]string{bankName, stmt.UniqueIdentifier, FormatAmount(stmt.Amount), stmt.Direction, stmt.Date}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return written, err
+	}
+	written = append(written, filePath)
+
+	filePath = filepath.Join(dir, MatchedPairsCSV)
+	err = writeCSV(filePath, matchedPairsHeader, func(write func([]string) error) error {
+		writePair := func(pair model.MatchedPair) error {
+			return write([]string{
+				pair.System.TrxID, FormatAmount(pair.System.Amount), pair.System.Type, pair.System.TransactionTime,
+				pair.BankName, pair.Bank.UniqueIdentifier, FormatAmount(pair.Bank.Amount), pair.Bank.Direction, pair.Bank.Date,
+				pair.Rule, strconv.Itoa(pair.DateDeltaDays), FormatAmount(pair.AmountDelta),
+			})
+		}
+
+		// Pairs streamed to MATCHED_PAIRS_FILE are not in the output, they are copied from that file
+		if output.MatchedPairsFile != "" {
+			return ReadMatchedPairs(output.MatchedPairsFile, writePair)
+		}
+
+		for _, pair := range output.MatchedPairs {
+			if err := writePair(pair); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return written, err
+	}
+	written = append(written, filePath)
+
+	filePath = filepath.Join(dir, RejectedRecordsCSV)
+	if err := WriteRejectedRecordsCSV(filePath, output.RejectedRecords); err != nil {
+		return written, err
+	}
+	written = append(written, filePath)
+
+	return written, nil
+}
+
+// writeCSV creates a csv file with the header, rows writes the rows
+func writeCSV(filePath string, header []string, rows func(write func([]string) error) error) (err error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", filePath, err)
+	}
+	// The file is closed once, its error is returned when everything else succeeded
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close %s: %w", filePath, closeErr)
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("write %s: %w", filePath, err)
+	}
+
+	if err := rows(writer.Write); err != nil {
+		return fmt.Errorf("write %s: %w", filePath, err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("write %s: %w", filePath, err)
+	}
+
+	return nil
+}
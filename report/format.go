@@ -0,0 +1,9 @@
+package report
+
+import "strconv"
+
+// FormatAmount is how every report writes an amount: a plain number with two decimals and
+// a leading minus for debits, e.g. -6241250.16
+func FormatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
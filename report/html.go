@@ -0,0 +1,134 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+//go:embed templates/report.html
+var htmlTemplate string
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"amount": FormatAmount,
+	"number": func(value float64) string { return strconv.FormatFloat(value, 'f', -1, 64) },
+}).Parse(htmlTemplate))
+
+// bankSummary is a row of the per bank breakdown of the html report
+type bankSummary struct {
+	BankName        string
+	LoadedRows      int
+	RejectedRows    int
+	Matched         int
+	Unmatched       int
+	UnmatchedAmount float64
+	BalanceStatus   string
+}
+
+type unmatchedBankRow struct {
+	BankName string
+	Record   model.BankStatementRecord
+}
+
+// reportFile is an input file with its ingestion decision and profile
+type reportFile struct {
+	model.IngestionDecision
+	TotalRows    int
+	AcceptedRows int
+}
+
+type htmlData struct {
+	*model.Output
+	SchemaVersion     string
+	Banks             []bankSummary
+	UnmatchedBankRows []unmatchedBankRow
+	Files             []reportFile
+	AgingBanks        []string
+}
+
+// WriteHTML writes the output as a single html file with inline styles and scripts, so it can be archived and
+// opened offline
+func WriteHTML(filePath string, output *model.Output) (err error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", filePath, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close %s: %w", filePath, closeErr)
+		}
+	}()
+
+	if err := htmlReport.Execute(file, newHTMLData(output)); err != nil {
+		return fmt.Errorf("write %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+func newHTMLData(output *model.Output) htmlData {
+	data := htmlData{Output: output, SchemaVersion: SchemaVersion}
+
+	banks := make(map[string]*bankSummary)
+	bank := func(bankName string) *bankSummary {
+		if banks[bankName] == nil {
+			banks[bankName] = &bankSummary{BankName: bankName}
+		}
+		return banks[bankName]
+	}
+
+	profiles := make(map[string]model.FileProfile, len(output.FileProfiles))
+	for _, profile := range output.FileProfiles {
+		profiles[profile.FilePath] = profile
+		if profile.BankName == "" {
+			continue
+		}
+		summary := bank(profile.BankName)
+		summary.LoadedRows += profile.AcceptedRows
+		summary.RejectedRows += profile.RejectedRows
+	}
+
+	// The matched pairs may be in a file, the breakdown always has the count
+	for bankName, breakdown := range output.ByBank {
+		bank(bankName).Matched = breakdown.Matched
+	}
+
+	for _, bankName := range sortedKeys(output.UnmatchedBankStmts) {
+		summary := bank(bankName)
+		for _, stmt := range output.UnmatchedBankStmts[bankName] {
+			summary.Unmatched++
+			summary.UnmatchedAmount += math.Abs(stmt.Amount)
+			data.UnmatchedBankRows = append(data.UnmatchedBankRows, unmatchedBankRow{BankName: bankName, Record: stmt})
+		}
+	}
+
+	// A bank with several statements is only balanced when all of them are
+	for _, check := range output.BalanceChecks {
+		summary := bank(check.BankName)
+		if summary.BalanceStatus == "" || summary.BalanceStatus == model.BalanceBalanced || check.Status == model.BalanceBreak {
+			summary.BalanceStatus = check.Status
+		}
+	}
+
+	for _, bankName := range sortedKeys(banks) {
+		data.Banks = append(data.Banks, *banks[bankName])
+	}
+
+	data.AgingBanks = agingBankNames(output.Aging)
+
+	for _, decision := range output.Metadata.Ingestion {
+		file := reportFile{IngestionDecision: decision}
+		if decision.Decision != model.IngestionRefused {
+			file.TotalRows = profiles[decision.File].TotalRows
+			file.AcceptedRows = profiles[decision.File].AcceptedRows
+		}
+		data.Files = append(data.Files, file)
+	}
+
+	return data
+}
@@ -0,0 +1,26 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// WriteIngestionDecisions prints the files which were refused or deduplicated, loaded files are only counted
+func WriteIngestionDecisions(w io.Writer, decisions []model.IngestionDecision) {
+	loaded := 0
+	for _, decision := range decisions {
+		if decision.Decision != model.IngestionRefused {
+			loaded++
+		}
+	}
+
+	fmt.Fprintf(w, "Files loaded: %d of %d\n", loaded, len(decisions))
+	for _, decision := range decisions {
+		if decision.Decision == model.IngestionLoaded {
+			continue
+		}
+		fmt.Fprintf(w, " + %s: %s, %s\n", decision.File, decision.Decision, decision.Message)
+	}
+}
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// SchemaVersion is the version of the json output, it changes whenever a field is renamed or removed
+const SchemaVersion = "1"
+
+// jsonReport is the document written in json mode, the fields of the output are at the top level
+type jsonReport struct {
+	SchemaVersion string `json:"schema_version"`
+	*model.Output
+}
+
+// WriteJSON writes the full output as a single json document. Lists which are empty are written as [] and not null.
+func WriteJSON(w io.Writer, output *model.Output) error {
+	normalized := model.Output{}
+	if output != nil {
+		normalized = *output
+	}
+	if normalized.DiscrepancyBreaches == nil {
+		normalized.DiscrepancyBreaches = []model.QualityBreach{}
+	}
+	if normalized.MatchedPairs == nil {
+		normalized.MatchedPairs = []model.MatchedPair{}
+	}
+	if normalized.UnmatchedSystemTransactions == nil {
+		normalized.UnmatchedSystemTransactions = []model.InternalTransactionRecord{}
+	}
+	if normalized.UnmatchedBankStmts == nil {
+		normalized.UnmatchedBankStmts = map[string][]model.BankStatementRecord{}
+	}
+	if normalized.RejectedRecords == nil {
+		normalized.RejectedRecords = []model.RejectedRecord{}
+	}
+	if normalized.RecordWarnings == nil {
+		normalized.RecordWarnings = []model.RecordWarning{}
+	}
+	if normalized.FileProfiles == nil {
+		normalized.FileProfiles = []model.FileProfile{}
+	}
+	if normalized.BalanceChecks == nil {
+		normalized.BalanceChecks = []model.BalanceCheck{}
+	}
+	if normalized.ByBank == nil {
+		normalized.ByBank = map[string]*model.Breakdown{}
+	}
+	if normalized.ByDay == nil {
+		normalized.ByDay = map[string]*model.Breakdown{}
+	}
+	if normalized.ByBankDay == nil {
+		normalized.ByBankDay = map[string]map[string]*model.Breakdown{}
+	}
+	if normalized.Aging.Items == nil {
+		normalized.Aging.Items = []model.AgedItem{}
+	}
+	if normalized.Aging.Buckets == nil {
+		normalized.Aging.Buckets = []model.AgingBucketSummary{}
+	}
+	if normalized.Metadata.Ingestion == nil {
+		normalized.Metadata.Ingestion = []model.IngestionDecision{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(jsonReport{SchemaVersion: SchemaVersion, Output: &normalized}); err != nil {
+		return fmt.Errorf("encode output: %w", err)
+	}
+	return nil
+}
+
+// inspectionReport is the document written by inspect, the parts of the output known before reconciling
+type inspectionReport struct {
+	SchemaVersion   string                 `json:"schema_version"`
+	RejectedRecords []model.RejectedRecord `json:"rejected_records"`
+	RecordWarnings  []model.RecordWarning  `json:"record_warnings"`
+	FileProfiles    []model.FileProfile    `json:"file_profiles"`
+	BalanceChecks   []model.BalanceCheck   `json:"balance_checks"`
+	Metadata        model.RunMetadata      `json:"metadata"`
+}
+
+// WriteInspectionJSON writes what was loaded from the input files, with the fields of the full output
+func WriteInspectionJSON(w io.Writer, output *model.Output) error {
+	inspection := inspectionReport{
+		SchemaVersion:   SchemaVersion,
+		RejectedRecords: output.RejectedRecords,
+		RecordWarnings:  output.RecordWarnings,
+		FileProfiles:    output.FileProfiles,
+		BalanceChecks:   output.BalanceChecks,
+		Metadata:        output.Metadata,
+	}
+	if inspection.RejectedRecords == nil {
+		inspection.RejectedRecords = []model.RejectedRecord{}
+	}
+	if inspection.RecordWarnings == nil {
+		inspection.RecordWarnings = []model.RecordWarning{}
+	}
+	if inspection.FileProfiles == nil {
+		inspection.FileProfiles = []model.FileProfile{}
+	}
+	if inspection.BalanceChecks == nil {
+		inspection.BalanceChecks = []model.BalanceCheck{}
+	}
+	if inspection.Metadata.Ingestion == nil {
+		inspection.Metadata.Ingestion = []model.IngestionDecision{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(inspection); err != nil {
+		return fmt.Errorf("encode inspection: %w", err)
+	}
+	return nil
+}
+
+// ReadJSON reads results written by WriteJSON, they must have the current schema version
+func ReadJSON(filePath string) (*model.Output, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filePath, err)
+	}
+
+	stored := jsonReport{Output: &model.Output{}}
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filePath, err)
+	}
+
+	if stored.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("read %s: schema version %q is not supported, expected %q", filePath, stored.SchemaVersion, SchemaVersion)
+	}
+
+	return stored.Output, nil
+}
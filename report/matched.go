@@ -0,0 +1,89 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// MatchedPairsFile writes matched pairs as json lines while reconciling, one pair per line.
+// It is safe for concurrent use, the first write error is returned by Close.
+type MatchedPairsFile struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	count   int
+	err     error
+}
+
+func CreateMatchedPairsFile(filePath string) (*MatchedPairsFile, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %w", filePath, err)
+	}
+
+	writer := bufio.NewWriter(file)
+	return &MatchedPairsFile{path: filePath, file: file, writer: writer, encoder: json.NewEncoder(writer)}, nil
+}
+
+func (f *MatchedPairsFile) WriteMatchedPair(pair model.MatchedPair) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return
+	}
+	if err := f.encoder.Encode(pair); err != nil {
+		f.err = fmt.Errorf("write %s: %w", f.path, err)
+		return
+	}
+	f.count++
+}
+
+// Count is the number of pairs written so far
+func (f *MatchedPairsFile) Count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.count
+}
+
+func (f *MatchedPairsFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.writer.Flush(); err != nil && f.err == nil {
+		f.err = fmt.Errorf("write %s: %w", f.path, err)
+	}
+	if err := f.file.Close(); err != nil && f.err == nil {
+		f.err = fmt.Errorf("close %s: %w", f.path, err)
+	}
+	return f.err
+}
+
+// ReadMatchedPairs reads a file written by MatchedPairsFile and calls pair for each of its pairs, one at a time
+// so the pairs of a large run are not held in memory
+func ReadMatchedPairs(filePath string, pair func(model.MatchedPair) error) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for line := 1; decoder.More(); line++ {
+		var matched model.MatchedPair
+		if err := decoder.Decode(&matched); err != nil {
+			return fmt.Errorf("read %s: pair %d: %w", filePath, line, err)
+		}
+		if err := pair(matched); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// WriteFileProfiles prints what was loaded from each input file
+func WriteFileProfiles(w io.Writer, profiles []model.FileProfile) {
+	for _, profile := range profiles {
+		fmt.Fprintf(w, "\nProfile of %s (%s", profile.FilePath, profile.RecordType)
+		if profile.BankName != "" {
+			fmt.Fprintf(w, ", %s", profile.BankName)
+		}
+		fmt.Fprintln(w, ")")
+
+		if profile.Dialect.Encoding != "" {
+			bom := ""
+			if profile.Dialect.BOM {
+				bom = " with BOM"
+			}
+			fmt.Fprintf(w, " Format: %s%s, delimiter %q\n", profile.Dialect.Encoding, bom, profile.Dialect.Delimiter)
+		}
+
+		fmt.Fprintf(w, " Total rows: %d\n", profile.TotalRows)
+		fmt.Fprintf(w, " Accepted rows: %d\n", profile.AcceptedRows)
+		fmt.Fprintf(w, " Rejected rows: %d\n", profile.RejectedRows)
+		fmt.Fprintf(w, " Rows outside the date range: %d\n", profile.OutOfRangeRows)
+		if profile.DuplicateRows > 0 {
+			fmt.Fprintf(w, " Duplicate rows: %d\n", profile.DuplicateRows)
+		}
+
+		if len(profile.RejectedByReason) > 0 {
+			fmt.Fprintln(w, " Rejected by reason:")
+		}
+		for _, reason := range sortedKeys(profile.RejectedByReason) {
+			fmt.Fprintf(w, "  - %s: %d\n", reason, profile.RejectedByReason[reason])
+		}
+
+		if profile.AcceptedRows == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, " Dates: %s to %s\n", profile.MinDate, profile.MaxDate)
+		fmt.Fprintf(w, " Sum of credits: %.2f\n", profile.SumCredits)
+		fmt.Fprintf(w, " Sum of debits: %.2f\n", profile.SumDebits)
+
+		fmt.Fprintln(w, " Rows per day:")
+		for _, day := range sortedKeys(profile.CountPerDay) {
+			fmt.Fprintf(w, "  - %s: %d\n", day, profile.CountPerDay[day])
+		}
+
+		fmt.Fprintln(w, " Largest amounts:")
+		for _, largest := range profile.LargestAmounts {
+			fmt.Fprintf(w, "  - %s: %.2f on %s\n", largest.Identifier, largest.Amount, largest.Date)
+		}
+	}
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
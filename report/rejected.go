@@ -0,0 +1,29 @@
+package report
+
+import (
+	"strconv"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+var rejectedRecordsHeader = []string{"source_file", "line", "field", "reason", "message", "raw"}
+
+// WriteRejectedRecordsCSV writes the rejected rows to a csv file which can be sent back to the data owners
+func WriteRejectedRecordsCSV(filePath string, records []model.RejectedRecord) error {
+	return writeCSV(filePath, rejectedRecordsHeader, func(write func([]string) error) error {
+		for _, record := range records {
+			row := []string{
+				record.SourceFile,
+				strconv.Itoa(record.Line),
+				record.Field,
+				record.Reason,
+				record.Message,
+				record.Raw,
+			}
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
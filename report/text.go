@@ -0,0 +1,56 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// WriteText prints the reconciliation results for a person reading the terminal
+func WriteText(w io.Writer, output *model.Output) {
+	fmt.Fprintln(w, "\n---- Reconciliation results: ----")
+	if output == nil {
+		fmt.Fprintln(w, "No records to display.")
+		return
+	}
+
+	fmt.Fprintf(w, "Total processed records: %d\n", output.TotalProcessedRecords)
+	fmt.Fprintf(w, "Total matched transactions: %d\n", output.TotalMatchedTransactions)
+	fmt.Fprintf(w, "Total unmatched transactions: %d\n", output.TotalUnmatchedTransactions)
+	fmt.Fprintf(w, "Total invalid records: %d\n", output.TotalInvalidRecords)
+	fmt.Fprintf(w, "Total discrepancies: %s\n", FormatAmount(output.TotalDiscrepancies))
+	if output.Outcome != "" {
+		fmt.Fprintf(w, "Outcome: %s\n", output.Outcome)
+	}
+	fmt.Fprintln(w)
+	if output.MatchedPairsFile != "" {
+		fmt.Fprintf(w, "Matched pairs written to %s\n", output.MatchedPairsFile)
+	} else {
+		// Only the count, a large run has too many pairs to read on a terminal
+		fmt.Fprintf(w, "Matched pairs: %d\n", len(output.MatchedPairs))
+	}
+	fmt.Fprintf(w, "Unmatched system transactions: %d\n", output.TotalUnmatchedSystemTransactions)
+	for _, trx := range output.UnmatchedSystemTransactions {
+		fmt.Fprintf(w, " - %s: %s on %s\n", trx.TrxID, FormatAmount(trx.Amount), trx.TransactionTime)
+	}
+	fmt.Fprintf(w, "Unmatched bank statements: %d\n", output.TotalUnmatchedBankStmts)
+	for _, bankName := range sortedKeys(output.UnmatchedBankStmts) {
+		fmt.Fprintf(w, " + %s\n", bankName)
+		for _, stmt := range output.UnmatchedBankStmts[bankName] {
+			fmt.Fprintf(w, "   - %s: %s on %s\n", stmt.UniqueIdentifier, FormatAmount(stmt.Amount), stmt.Date)
+		}
+	}
+	WriteBreakdowns(w, output)
+	WriteAging(w, output.Aging)
+	WriteBalanceChecks(w, output.BalanceChecks)
+	fmt.Fprintf(w, "Rejected records: %d\n", len(output.RejectedRecords))
+	for _, rejected := range output.RejectedRecords {
+		fmt.Fprintf(w, " - %s:%d [%s] %s\n", rejected.SourceFile, rejected.Line, rejected.Reason, rejected.Message)
+	}
+
+	fmt.Fprintf(w, "Record warnings: %d\n", len(output.RecordWarnings))
+	for _, warning := range output.RecordWarnings {
+		fmt.Fprintf(w, " - %s:%d [%s] %s\n", warning.SourceFile, warning.Line, warning.Rule, warning.Message)
+	}
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// maxRejectedRowsShown is the number of rejected rows listed per file, the reasons count them all
+const maxRejectedRowsShown = 10
+
+// WriteValidation prints the problems found in each input file and the data quality thresholds crossed
+func WriteValidation(w io.Writer, validation model.Validation) {
+	for _, file := range validation.Files {
+		fmt.Fprintf(w, "%s (%s): ", file.FilePath, file.RecordType)
+
+		switch {
+		case file.Error != "":
+			fmt.Fprintf(w, "error: %s\n", file.Error)
+			continue
+		case file.Ingestion != nil && file.Ingestion.Decision == model.IngestionRefused:
+			fmt.Fprintf(w, "not loaded, %s\n", file.Ingestion.Message)
+			continue
+		case file.Profile == nil:
+			fmt.Fprintln(w, "not loaded")
+			continue
+		}
+
+		profile := file.Profile
+		status := "ok"
+		if profile.RejectedRows > 0 || len(file.Breaches) > 0 {
+			status = "problems found"
+		}
+		fmt.Fprintf(w, "%s, %d rows, %d accepted, %d rejected, %d outside the date range\n",
+			status, profile.TotalRows, profile.AcceptedRows, profile.RejectedRows, profile.OutOfRangeRows)
+
+		for _, reason := range sortedKeys(profile.RejectedByReason) {
+			fmt.Fprintf(w, " - %s: %d\n", reason, profile.RejectedByReason[reason])
+		}
+
+		shown := 0
+		for _, rejected := range file.Rejected {
+			if rejected.Reason == model.ReasonOutOfRange {
+				continue
+			}
+			if shown == maxRejectedRowsShown {
+				fmt.Fprintf(w, "   ... and %d more rejected rows\n", profile.RejectedRows-shown)
+				break
+			}
+			fmt.Fprintf(w, "   line %d [%s] %s\n", rejected.Line, rejected.Reason, rejected.Message)
+			shown++
+		}
+
+		for _, breach := range file.Breaches {
+			fmt.Fprintf(w, " - [%s] %s\n", breach.Threshold, breach.Message)
+		}
+	}
+
+	for _, breach := range validation.Breaches {
+		fmt.Fprintf(w, "[%s] %s\n", breach.Threshold, breach.Message)
+	}
+
+	switch {
+	case validation.Unusable():
+		fmt.Fprintln(w, "\nValidation failed: some input files cannot be used.")
+	case validation.QualityFailed():
+		fmt.Fprintln(w, "\nValidation failed: a data quality threshold was crossed, reconciliation would not start.")
+	default:
+		fmt.Fprintln(w, "\nValidation passed.")
+	}
+}
@@ -0,0 +1,373 @@
+package report
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// Cell styles of the workbook, indexes into cellXfs of xlsxStyles
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleAmount
+	xlsxStyleDate
+	xlsxStyleDateTime
+	xlsxStyleHeader
+)
+
+// xlsxMaxSheetName is the longest sheet name Excel accepts
+const xlsxMaxSheetName = 31
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+%s</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="3">
+<numFmt numFmtId="164" formatCode="#,##0.00"/>
+<numFmt numFmtId="165" formatCode="yyyy-mm-dd"/>
+<numFmt numFmtId="166" formatCode="yyyy-mm-dd hh:mm:ss"/>
+</numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="5">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+</cellXfs>
+</styleSheet>`
+
+// xlsxEpoch is day zero of the Excel date serial numbers
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxCell is a cell of a worksheet, either a number with a style or an inline string
+type xlsxCell struct {
+	text   string
+	number float64
+	style  int
+	isText bool
+}
+
+func textCell(text string) xlsxCell {
+	return xlsxCell{text: text, isText: true}
+}
+
+func numberCell(number float64) xlsxCell {
+	return xlsxCell{number: number}
+}
+
+func amountCell(amount float64) xlsxCell {
+	return xlsxCell{number: amount, style: xlsxStyleAmount}
+}
+
+// dateCell is a date cell when the value has the layout, and a text cell otherwise
+func dateCell(value string, layout string) xlsxCell {
+	parsed, err := time.Parse(layout, value)
+	if err != nil {
+		return textCell(value)
+	}
+
+	days := parsed.Sub(xlsxEpoch).Hours() / 24
+	if layout == "2006-01-02" {
+		return xlsxCell{number: days, style: xlsxStyleDate}
+	}
+	return xlsxCell{number: days, style: xlsxStyleDateTime}
+}
+
+// xlsxSheet is a worksheet and the function writing its rows
+type xlsxSheet struct {
+	name   string
+	header []string
+	widths []float64
+	rows   func(write func(...xlsxCell) error) error
+}
+
+// WriteXLSX writes the output as an Excel workbook with a Summary, Matched, Unmatched System,
+// an Unmatched Bank sheet per bank and a Rejected sheet. Rows are streamed to the file, so the
+// workbook of a large run is not built in memory.
+func WriteXLSX(filePath string, output *model.Output) (err error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", filePath, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("close %s: %w", filePath, closeErr)
+		}
+	}()
+
+	if err := writeWorkbook(file, workbookSheets(output)); err != nil {
+		return fmt.Errorf("write %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+func workbookSheets(output *model.Output) []xlsxSheet {
+	sheets := []xlsxSheet{
+		{
+			name:   "Summary",
+			header: []string{"metric", "value"},
+			widths: []float64{36, 24},
+			rows: func(write func(...xlsxCell) error) error {
+				rows := [][]xlsxCell{
+					{textCell("started_at"), dateCell(output.Metadata.StartedAt, time.RFC3339)},
+					{textCell("total_processed_records"), numberCell(float64(output.TotalProcessedRecords))},
+					{textCell("total_matched_transactions"), numberCell(float64(output.TotalMatchedTransactions))},
+					{textCell("total_unmatched_transactions"), numberCell(float64(output.TotalUnmatchedTransactions))},
+					{textCell("total_unmatched_system_transactions"), numberCell(float64(output.TotalUnmatchedSystemTransactions))},
+					{textCell("total_unmatched_bank_stmts"), numberCell(float64(output.TotalUnmatchedBankStmts))},
+					{textCell("total_invalid_records"), numberCell(float64(output.TotalInvalidRecords))},
+					{textCell("total_discrepancies"), amountCell(output.TotalDiscrepancies)},
+					{textCell("rejected_records"), numberCell(float64(len(output.RejectedRecords)))},
+				}
+				if output.MatchedPairsFile != "" {
+					rows = append(rows, []xlsxCell{textCell("matched_pairs_file"), textCell(output.MatchedPairsFile)})
+				}
+				for _, row := range rows {
+					if err := write(row...); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			name:   "Matched",
+			header: matchedPairsHeader,
+			widths: []float64{14, 16, 10, 20, 10, 18, 16, 10, 12, 30, 16, 14},
+			rows: func(write func(...xlsxCell) error) error {
+				for _, pair := range output.MatchedPairs {
+					err := write(
+						textCell(pair.System.TrxID), amountCell(pair.System.Amount), textCell(pair.System.Type), dateCell(pair.System.TransactionTime, time.RFC3339),
+						textCell(pair.BankName), textCell(pair.Bank.UniqueIdentifier), amountCell(pair.Bank.Amount), textCell(pair.Bank.Direction), dateCell(pair.Bank.Date, "2006-01-02"),
+						textCell(pair.Rule), numberCell(float64(pair.DateDeltaDays)), amountCell(pair.AmountDelta),
+					)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			name:   "Unmatched System",
+			header: unmatchedSystemTransactionsHeader,
+			widths: []float64{14, 16, 10, 20},
+			rows: func(write func(...xlsxCell) error) error {
+				for _, trx := range output.UnmatchedSystemTransactions {
+					if err := write(textCell(trx.TrxID), amountCell(trx.Amount), textCell(trx.Type), dateCell(trx.TransactionTime, time.RFC3339)); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	}
+
+	// Sheet names are not case sensitive in Excel, names has them in lower case
+	names := map[string]bool{"summary": true, "matched": true, "unmatched system": true, "rejected": true}
+	for _, bankName := range sortedKeys(output.UnmatchedBankStmts) {
+		stmts := output.UnmatchedBankStmts[bankName]
+		sheets = append(sheets, xlsxSheet{
+			name:   uniqueSheetName("Unmatched "+bankName, names),
+			header: []string{"unique_identifier", "amount", "direction", "date"},
+			widths: []float64{18, 16, 10, 12},
+			rows: func(write func(...xlsxCell) error) error {
+				for _, stmt := range stmts {
+					if err := write(textCell(stmt.UniqueIdentifier), amountCell(stmt.Amount), textCell(stmt.Direction), dateCell(stmt.Date, "2006-01-02")); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		})
+	}
+
+	sheets = append(sheets, xlsxSheet{
+		name:   "Rejected",
+		header: rejectedRecordsHeader,
+		widths: []float64{30, 8, 18, 20, 50, 50},
+		rows: func(write func(...xlsxCell) error) error {
+			for _, rejected := range output.RejectedRecords {
+				err := write(textCell(rejected.SourceFile), numberCell(float64(rejected.Line)), textCell(rejected.Field), textCell(rejected.Reason), textCell(rejected.Message), textCell(rejected.Raw))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+
+	return sheets
+}
+
+// uniqueSheetName makes a valid sheet name which is not in names yet regardless of case, and adds it to names
+func uniqueSheetName(name string, names map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	candidate := truncateRunes(name, xlsxMaxSheetName)
+	for i := 2; names[strings.ToLower(candidate)]; i++ {
+		suffix := " (" + strconv.Itoa(i) + ")"
+		candidate = truncateRunes(name, xlsxMaxSheetName-len(suffix)) + suffix
+	}
+
+	names[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+func writeWorkbook(w io.Writer, sheets []xlsxSheet) error {
+	archive := zip.NewWriter(w)
+
+	var overrides, workbook, rels strings.Builder
+	for i, sheet := range sheets {
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`+"\n", i+1, i+1)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`+"\n", len(sheets)+1)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, overrides.String())},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + workbook.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+` + rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for _, part := range parts {
+		partWriter, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(partWriter, part.content); err != nil {
+			return err
+		}
+	}
+
+	for i, sheet := range sheets {
+		partWriter, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := writeSheet(partWriter, sheet); err != nil {
+			return fmt.Errorf("sheet %s: %w", sheet.name, err)
+		}
+	}
+
+	return archive.Close()
+}
+
+// writeSheet writes a worksheet with its header row frozen
+func writeSheet(w io.Writer, sheet xlsxSheet) error {
+	buffered := bufio.NewWriter(w)
+
+	buffered.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	buffered.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	if len(sheet.widths) > 0 {
+		buffered.WriteString("<cols>")
+		for i, width := range sheet.widths {
+			fmt.Fprintf(buffered, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64))
+		}
+		buffered.WriteString("</cols>")
+	}
+	buffered.WriteString("<sheetData>")
+
+	rowNumber := 0
+	write := func(cells ...xlsxCell) error {
+		rowNumber++
+		fmt.Fprintf(buffered, `<row r="%d">`, rowNumber)
+		for i, cell := range cells {
+			reference := columnName(i) + strconv.Itoa(rowNumber)
+			if cell.isText {
+				fmt.Fprintf(buffered, `<c r="%s" t="inlineStr"`, reference)
+				if cell.style != xlsxStyleDefault {
+					fmt.Fprintf(buffered, ` s="%d"`, cell.style)
+				}
+				buffered.WriteString(`><is><t xml:space="preserve">`)
+				xml.EscapeText(buffered, []byte(cell.text))
+				buffered.WriteString("</t></is></c>")
+				continue
+			}
+			fmt.Fprintf(buffered, `<c r="%s"`, reference)
+			if cell.style != xlsxStyleDefault {
+				fmt.Fprintf(buffered, ` s="%d"`, cell.style)
+			}
+			fmt.Fprintf(buffered, "><v>%s</v></c>", strconv.FormatFloat(cell.number, 'f', -1, 64))
+		}
+		_, err := buffered.WriteString("</row>")
+		return err
+	}
+
+	header := make([]xlsxCell, len(sheet.header))
+	for i, name := range sheet.header {
+		header[i] = xlsxCell{text: name, style: xlsxStyleHeader, isText: true}
+	}
+	if err := write(header...); err != nil {
+		return err
+	}
+
+	if err := sheet.rows(write); err != nil {
+		return err
+	}
+
+	buffered.WriteString("</sheetData></worksheet>")
+	return buffered.Flush()
+}
+
+// columnName is the letter name of a zero based column index: A, B, ..., Z, AA, ...
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sientong/reconciliation-service/cli"
+	"github.com/sientong/reconciliation-service/report"
+)
+
+// runReport writes results stored with --format json again, in any of the output formats
+func runReport(args []string) int {
+	flags := cli.NewFlagSet("report", "results.json",
+		"Writes results stored with 'reconcile --format json' to stdout as text or json, and to csv, html\n"+
+			"or xlsx files, without reconciling again.")
+	var out outputFlags
+	var settings settingsFlags
+	out.register(flags)
+	settings.register(flags)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	cfg, err := settings.load(flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+	out.merge(flags, cfg)
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid settings:\n%v\n", err)
+		return exitInputFailure
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Error: expected a result file, got %d arguments\n", flags.NArg())
+		flags.Usage()
+		return exitInputFailure
+	}
+
+	output, err := report.ReadJSON(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	if !out.write(output, out.logs()) {
+		return exitInternalError
+	}
+	return exitSuccess
+}
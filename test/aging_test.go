@@ -0,0 +1,104 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+)
+
+func TestAging_WithDefaultBuckets(t *testing.T) {
+
+	loadSmallDataset(t)
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	if err := AgeUnmatched(output, "20250610"); err != nil {
+		t.Fatalf("Expected no error aging unmatched items, but got: %v", err)
+	}
+
+	aging := output.Aging
+	if aging.ReferenceDate != "2025-06-10" || len(aging.Buckets) != 5 {
+		t.Fatalf("Expected 5 buckets on 2025-06-10, got: %d on %s", len(aging.Buckets), aging.ReferenceDate)
+	}
+
+	if len(aging.Items) != output.TotalUnmatchedTransactions {
+		t.Errorf("Expected every unmatched item to be aged, got: %d of %d", len(aging.Items), output.TotalUnmatchedTransactions)
+	}
+
+	for _, item := range aging.Items {
+		if item.AgeDays != 5 || item.Bucket != "4-7" {
+			t.Errorf("Expected %s to be 5 days old in bucket 4-7, got: %d days in %s", item.Identifier, item.AgeDays, item.Bucket)
+		}
+	}
+
+	bucket := aging.Buckets[2]
+	if bucket.Count != 4 || bucket.System.Count != 2 || bucket.ByBank["bankA"].Count != 1 || bucket.ByBank["bankB"].Count != 1 {
+		t.Errorf("Expected 2 system and 1 unmatched item per bank in 4-7, got: %+v", bucket)
+	}
+
+	if bucket.ByBank["bankA"].Amount != 3955387.85 {
+		t.Errorf("Expected 3955387.85 unmatched for bankA, got: %.2f", bucket.ByBank["bankA"].Amount)
+	}
+
+	clearRecords()
+}
+
+func TestAging_WithConfiguredBuckets(t *testing.T) {
+
+	buckets, err := ParseAgingBuckets("0-4,5-9,>9")
+	if err != nil {
+		t.Fatalf("Expected valid buckets, but got: %v", err)
+	}
+	defaultBuckets := AgingBuckets
+	AgingBuckets = buckets
+	defer func() { AgingBuckets = defaultBuckets }()
+
+	loadSmallDataset(t)
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	// A reference date before the items makes them 0 days old, in the first bucket
+	if err := AgeUnmatched(output, "20250601"); err != nil {
+		t.Fatalf("Expected no error aging unmatched items, but got: %v", err)
+	}
+
+	if output.Aging.Buckets[0].Count != 4 {
+		t.Errorf("Expected 4 items dated after the reference date in the first bucket, got: %+v", output.Aging.Buckets[0])
+	}
+
+	for _, item := range output.Aging.Items {
+		if item.AgeDays != 0 {
+			t.Errorf("Expected %s dated after the reference date to be 0 days old, got: %d", item.Identifier, item.AgeDays)
+		}
+	}
+
+	if err := AgeUnmatched(output, "20250615"); err != nil {
+		t.Fatalf("Expected no error aging unmatched items, but got: %v", err)
+	}
+
+	if output.Aging.Buckets[2].Count != 4 || output.Aging.Buckets[2].MaxDays != -1 {
+		t.Errorf("Expected 4 items in the open ended bucket, got: %+v", output.Aging.Buckets[2])
+	}
+
+	clearRecords()
+}
+
+func TestAging_WithInvalidBuckets(t *testing.T) {
+
+	for _, spec := range []string{"1-3,>3", "0-1,3-5,>5", "0-1,2-3", "0-1,>1,2-3", "0-x,>1", "3-1,>3"} {
+		if _, err := ParseAgingBuckets(spec); err == nil {
+			t.Errorf("Expected an error for buckets %s", spec)
+		}
+	}
+
+	if err := AgeUnmatched(&model.Output{}, "2025-06-10"); err == nil {
+		t.Errorf("Expected an error for a reference date which is not YYYYMMDD")
+	}
+}
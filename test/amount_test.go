@@ -0,0 +1,106 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/util"
+)
+
+func TestAmount_WithStandardFormat(t *testing.T) {
+	cases := map[string]float64{
+		"-6241250.16":    -6241250.16,
+		"1,234,567.89":   1234567.89,
+		"(1,000.00)":     -1000,
+		"1,000.00 DR":    -1000,
+		"1,000.00 CR":    1000,
+		"1,000.00-":      -1000,
+		"$ 1,000.50":     1000.5,
+		"-USD 12.5":      -12.5,
+		"3935387.85 IDR": 3935387.85,
+	}
+
+	for raw, expected := range cases {
+		amount, err := ParseAmount(raw, AmountFormats["standard"])
+		if err != nil {
+			t.Errorf("Expected no error for %s, but got: %v", raw, err)
+			continue
+		}
+		if amount != expected {
+			t.Errorf("Expected %s to be parsed as %.2f, got: %.2f", raw, expected, amount)
+		}
+	}
+}
+
+func TestAmount_WithIndonesianFormat(t *testing.T) {
+	cases := map[string]float64{
+		"1.234.567,89":    1234567.89,
+		"Rp 1.234.567,89": 1234567.89,
+		"Rp. 500.000":     500000,
+		"-Rp 1.000,50":    -1000.5,
+		"Rp -1.000,50":    -1000.5,
+		"(Rp 2.500)":      -2500,
+		"75.000 DR":       -75000,
+	}
+
+	for raw, expected := range cases {
+		amount, err := ParseAmount(raw, AmountFormats["id"])
+		if err != nil {
+			t.Errorf("Expected no error for %s, but got: %v", raw, err)
+			continue
+		}
+		if amount != expected {
+			t.Errorf("Expected %s to be parsed as %.2f, got: %.2f", raw, expected, amount)
+		}
+	}
+}
+
+func TestAmount_WithAutoFormat(t *testing.T) {
+	cases := map[string]float64{
+		"1.234.567,89": 1234567.89,
+		"1,234,567.89": 1234567.89,
+		"1.234.567":    1234567,
+		"1234,5":       1234.5,
+		"1234.56":      1234.56,
+		"1234.567":     1234.567,
+		"12345,678":    12345.678,
+	}
+
+	for raw, expected := range cases {
+		amount, err := ParseAmount(raw, AmountFormats["auto"])
+		if err != nil {
+			t.Errorf("Expected no error for %s, but got: %v", raw, err)
+			continue
+		}
+		if amount != expected {
+			t.Errorf("Expected %s to be parsed as %.2f, got: %.2f", raw, expected, amount)
+		}
+	}
+}
+
+func TestAmount_WithAmbiguousValue(t *testing.T) {
+	_, err := ParseAmount("1.234", AmountFormats["auto"])
+	if err == nil {
+		t.Fatalf("Expected an error for ambiguous amount, but got nil")
+	}
+
+	expectedMessage := "ambiguous amount \"1.234\": \".\" could be a decimal or a thousand separator"
+	if err.Error() != expectedMessage {
+		t.Errorf("Expected error message '%s', but got '%s'", expectedMessage, err.Error())
+	}
+
+	_, err = ParseAmount("-1,000.00 DR", AmountFormats["standard"])
+	if err == nil || !strings.HasPrefix(err.Error(), "ambiguous amount \"-1,000.00 DR\"") {
+		t.Errorf("Expected an ambiguous amount error for double sign markers, but got: %v", err)
+	}
+}
+
+func TestAmount_WithInvalidValue(t *testing.T) {
+	invalid := []string{"", "AS", "1.234,56", "12,34.00", "(100"}
+
+	for _, raw := range invalid {
+		if _, err := ParseAmount(raw, AmountFormats["standard"]); err == nil {
+			t.Errorf("Expected an error for %q, but got nil", raw)
+		}
+	}
+}
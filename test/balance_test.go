@@ -0,0 +1,157 @@
+package test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+)
+
+func TestBalance_WithInlineBalances(t *testing.T) {
+
+	clearRecords()
+
+	if err := CreateRecords("../csv/bankE_20250605.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.BankStatementRecordsMap["bankE"]) != 3 {
+		t.Errorf("Expected balance rows not to be bank statement records, got: %d records", len(model.BankStatementRecordsMap["bankE"]))
+	}
+
+	if model.LoadedFiles[0].TotalRows != 3 || len(model.RejectedRecords) != 0 {
+		t.Errorf("Expected 3 rows and no rejected records, got: %d rows and %d rejected", model.LoadedFiles[0].TotalRows, len(model.RejectedRecords))
+	}
+
+	if len(model.BalanceChecks) != 1 {
+		t.Fatalf("Expected 1 balance check, got: %d", len(model.BalanceChecks))
+	}
+
+	check := model.BalanceChecks[0]
+	if check.Status != model.BalanceBalanced || check.BankName != "bankE" {
+		t.Errorf("Expected bankE to be balanced, got: %+v", check)
+	}
+
+	if check.Opening == nil || *check.Opening != 10000000 || check.Closing == nil || *check.Closing != 11649525.54 {
+		t.Errorf("Expected opening 10000000.00 and closing 11649525.54, got: %v and %v", check.Opening, check.Closing)
+	}
+
+	clearRecords()
+}
+
+func TestBalance_WithSideFileAndOutOfRangeLines(t *testing.T) {
+
+	clearRecords()
+	defer func() {
+		model.BankBalances = make(map[string]model.StatementBalance)
+	}()
+
+	if err := LoadBankBalances("../csv/balances.csv"); err != nil {
+		t.Fatalf("Expected no error loading balances, but got: %v", err)
+	}
+
+	// Lines outside the date range are still part of the statement
+	if err := CreateBankStatementRecords([]string{"../csv/bankA_20250605.csv", "../csv/bankB_20250605.csv"}, "20250606", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.BalanceChecks) != 2 {
+		t.Fatalf("Expected 2 balance checks, got: %d", len(model.BalanceChecks))
+	}
+
+	if model.BalanceChecks[0].Status != model.BalanceBalanced {
+		t.Errorf("Expected bankA to be balanced, got: %+v", model.BalanceChecks[0])
+	}
+
+	check := model.BalanceChecks[1]
+	if check.Status != model.BalanceBreak || math.Abs(check.Difference+13488.66) > 0.001 {
+		t.Errorf("Expected bankB to break by -13488.66, got: %+v", check)
+	}
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	if len(output.BalanceChecks) != 2 {
+		t.Errorf("Expected 2 balance checks in output, got: %d", len(output.BalanceChecks))
+	}
+
+	clearRecords()
+}
+
+func TestBalance_WithSideFileInBankAmountFormat(t *testing.T) {
+
+	clearRecords()
+	defer func() {
+		model.BankBalances = make(map[string]model.StatementBalance)
+		delete(model.BankProfiles, "bankE")
+	}()
+
+	if err := SetBankAmountFormat("bankE", "id"); err != nil {
+		t.Fatalf("Expected a valid amount format, but got: %v", err)
+	}
+
+	balancesFile := filepath.Join(t.TempDir(), "balances.csv")
+	os.WriteFile(balancesFile, []byte("file,opening_balance,closing_balance\n"+
+		"bankE_20250605.csv,\"1.234,56\",(50)\n"), 0o644)
+
+	if err := LoadBankBalances(balancesFile); err != nil {
+		t.Fatalf("Expected no error loading balances, but got: %v", err)
+	}
+
+	balance := model.BankBalances["bankE_20250605.csv"]
+	if balance.Opening == nil || *balance.Opening != 1234.56 || balance.Closing == nil || *balance.Closing != -50 {
+		t.Errorf("Expected opening 1234.56 and closing -50.00, got: %v and %v", balance.Opening, balance.Closing)
+	}
+
+	clearRecords()
+}
+
+func TestBalance_WithoutBalances(t *testing.T) {
+
+	clearRecords()
+
+	if err := CreateRecords("../csv/bankC_20250605.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.BalanceChecks) != 1 || model.BalanceChecks[0].Status != model.BalanceNotSupplied {
+		t.Errorf("Expected a not_supplied balance check, got: %+v", model.BalanceChecks)
+	}
+
+	clearRecords()
+}
+
+func TestBalance_WithTolerance(t *testing.T) {
+
+	bankFile := filepath.Join(t.TempDir(), "bankE_20250605.csv")
+	os.WriteFile(bankFile, []byte("unique_identifier,amount,date\n"+
+		"OPENING_BALANCE,100.00,2025-06-05\n"+
+		"BE0001,50.00,2025-06-05\n"+
+		"CLOSING_BALANCE,150.01,2025-06-05\n"), 0o644)
+
+	expected := map[float64]string{
+		DefaultBalanceTolerance: model.BalanceBreak,
+		0.02:                    model.BalanceBalanced,
+	}
+
+	for tolerance, status := range expected {
+		clearRecords()
+		BalanceTolerance = tolerance
+
+		if err := CreateRecords(bankFile, "bankStatement", "20250601", "20250630"); err != nil {
+			t.Errorf("Expected no error for valid record, but got: %v", err)
+		}
+
+		if len(model.BalanceChecks) != 1 || model.BalanceChecks[0].Status != status {
+			t.Errorf("Expected a difference of 0.01 to be %s with tolerance %g, got: %+v", status, tolerance, model.BalanceChecks)
+		}
+	}
+
+	BalanceTolerance = DefaultBalanceTolerance
+	clearRecords()
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+)
+
+func TestBreakdown_WithEveryStrategy(t *testing.T) {
+
+	strategies := map[string]func() (*model.Output, error){
+		"simple":     SimpleReconciliation,
+		"concurrent": ConcurrentReconcilliation,
+		"indexed":    ConcurrentReconciliationIndexed,
+	}
+
+	expectedBankA := model.Breakdown{
+		Processed: 5, Matched: 2, UnmatchedBank: 1, MatchRate: 0.8,
+		SystemCredits: 3935387.85, SystemDebits: 6241250.16, BankCredits: 7890775.70, BankDebits: 6241250.16,
+	}
+
+	for name, reconcile := range strategies {
+		loadSmallDataset(t)
+
+		output, err := reconcile()
+		if err != nil {
+			t.Fatalf("%s: Expected no error during reconciliation, but got: %v", name, err)
+		}
+
+		if len(output.ByBank) != 2 || len(output.ByDay) != 1 {
+			t.Fatalf("%s: Expected 2 banks and 1 day, got: %d banks and %d days", name, len(output.ByBank), len(output.ByDay))
+		}
+
+		if !reflect.DeepEqual(roundBreakdown(*output.ByBank["bankA"]), expectedBankA) {
+			t.Errorf("%s: Expected bankA breakdown %+v, got: %+v", name, expectedBankA, *output.ByBank["bankA"])
+		}
+
+		day := output.ByDay["2025-06-05"]
+		if day.Processed != 12 || day.Matched != 4 || day.UnmatchedSystem != 2 || day.UnmatchedBank != 2 {
+			t.Errorf("%s: Expected 12 processed, 4 matched, 2 and 2 unmatched on 2025-06-05, got: %+v", name, day)
+		}
+
+		if !reflect.DeepEqual(output.ByBankDay["bankB"]["2025-06-05"], output.ByBank["bankB"]) {
+			t.Errorf("%s: Expected bankB on its only day to equal bankB, got: %+v", name, output.ByBankDay["bankB"]["2025-06-05"])
+		}
+	}
+
+	clearRecords()
+}
+
+func TestBreakdown_WithSeveralDays(t *testing.T) {
+
+	clearRecords()
+
+	dir := t.TempDir()
+	systemFile := filepath.Join(dir, "st_days.csv")
+	bankFile := filepath.Join(dir, "bankB_20250607.csv")
+
+	os.WriteFile(systemFile, []byte("trxID,amount,type,transactionTime\n"+
+		"TX0001,100.00,CREDIT,2025-06-06T08:00:00Z\n"+
+		"TX0002,200.00,DEBIT,2025-06-07T08:00:00Z\n"+
+		"TX0003,300.00,CREDIT,2025-06-07T09:00:00Z\n"), 0o644)
+	os.WriteFile(bankFile, []byte("unique_identifier,amount,date\n"+
+		"BB0001,100.00,2025-06-06\n"+
+		"BB0002,-250.00,2025-06-07\n"+
+		"BB0003,300.00,2025-06-07\n"), 0o644)
+
+	if err := CreateRecords(systemFile, "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := CreateBankStatementRecords([]string{bankFile}, "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	output, err := ConcurrentReconcilliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	june6 := output.ByBankDay["bankB"]["2025-06-06"]
+	if june6 == nil || june6.MatchRate != 1 {
+		t.Errorf("Expected every record of bankB on 2025-06-06 to be matched, got: %+v", june6)
+	}
+
+	june7 := output.ByDay["2025-06-07"]
+	expected := model.Breakdown{
+		Processed: 4, Matched: 1, UnmatchedSystem: 1, UnmatchedBank: 1, MatchRate: 0.5,
+		SystemCredits: 300, SystemDebits: 200, BankCredits: 300, BankDebits: 250,
+	}
+	if june7 == nil || !reflect.DeepEqual(*june7, expected) {
+		t.Errorf("Expected 2025-06-07 breakdown %+v, got: %+v", expected, june7)
+	}
+
+	// The unmatched system transaction is not attributed to a bank
+	bankJune7 := output.ByBankDay["bankB"]["2025-06-07"]
+	if bankJune7.UnmatchedSystem != 0 || bankJune7.Processed != 3 {
+		t.Errorf("Expected 3 processed and no unmatched system transaction for bankB on 2025-06-07, got: %+v", bankJune7)
+	}
+
+	clearRecords()
+}
+
+// roundBreakdown rounds the sums to cents, so they compare equal regardless of the order they were added in
+func roundBreakdown(breakdown model.Breakdown) model.Breakdown {
+	round := func(amount float64) float64 {
+		return float64(int64(amount*100+0.5)) / 100
+	}
+	breakdown.SystemCredits = round(breakdown.SystemCredits)
+	breakdown.SystemDebits = round(breakdown.SystemDebits)
+	breakdown.BankCredits = round(breakdown.BankCredits)
+	breakdown.BankDebits = round(breakdown.BankDebits)
+	return breakdown
+}
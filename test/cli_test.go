@@ -1,8 +1,16 @@
 package test
 
 import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/sientong/reconciliation-service/cli"
 	. "github.com/sientong/reconciliation-service/validator"
 )
 
@@ -84,6 +92,19 @@ func TestCLI_WithInvalidTransactionFileExtension(t *testing.T) {
 	}
 }
 
+func TestCLI_WithInvalidFirstOfSeveralTransactionFiles(t *testing.T) {
+	args := []string{"system_transactions.txt,system_transactions.csv", "bank_statements.csv", "20230101", "20231231"}
+
+	err := ValidateArgs(args)
+	if err == nil {
+		t.Fatalf("Expected error for invalid arguments, but got nil")
+	}
+	expectedMessage := "invalid file format for system transactions: expected .csv, got system_transactions.txt"
+	if err.Error() != expectedMessage {
+		t.Errorf("Expected error message '%s', but got '%s'", expectedMessage, err.Error())
+	}
+}
+
 func TestCLI_WithInvalidBankStatementFileExtension(t *testing.T) {
 	args := []string{"system_transactions.csv", "bank_statements.csv,bank_statements", "20230101", "20231231"}
 
@@ -96,3 +117,160 @@ func TestCLI_WithInvalidBankStatementFileExtension(t *testing.T) {
 		t.Errorf("Expected error message '%s', but got '%s'", expectedMessage, err.Error())
 	}
 }
+
+var cliCommands = []cli.Command{
+	{Name: "reconcile", Summary: "reconcile the files"},
+	{Name: "validate", Summary: "check the files"},
+}
+
+func TestCLI_WithCommandDispatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		cmdArgs  []string
+		err      error
+	}{
+		{"command", []string{"validate", "--system", "st.csv"}, "validate", []string{"--system", "st.csv"}, nil},
+		{"command without arguments", []string{"reconcile"}, "reconcile", []string{}, nil},
+		{"positional arguments", []string{"st.csv", "bankA_20250605.csv", "20250601", "20250630"}, "reconcile", []string{"st.csv", "bankA_20250605.csv", "20250601", "20250630"}, nil},
+		{"flags without command", []string{"--format", "json", "st.csv"}, "reconcile", []string{"--format", "json", "st.csv"}, nil},
+		{"help", []string{"help"}, "", nil, flag.ErrHelp},
+		{"help flag", []string{"--help"}, "", nil, flag.ErrHelp},
+		{"short help flag", []string{"-h"}, "", nil, flag.ErrHelp},
+		{"empty command line", nil, "", nil, cli.ErrNoCommand},
+	}
+
+	for _, test := range tests {
+		cmd, cmdArgs, err := cli.Dispatch(cliCommands, "reconcile", test.args)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: Expected error %v, got: %v", test.name, test.err, err)
+			continue
+		}
+		if cmd.Name != test.expected || (test.err == nil && !reflect.DeepEqual(cmdArgs, test.cmdArgs)) {
+			t.Errorf("%s: Expected command %q with %v, got: %q with %v", test.name, test.expected, test.cmdArgs, cmd.Name, cmdArgs)
+		}
+	}
+}
+
+func TestCLI_WithUnknownCommand(t *testing.T) {
+	_, _, err := cli.Dispatch(cliCommands, "reconcile", []string{"recocile", "--system", "st.csv"})
+	if err == nil || err.Error() != "unknown command recocile" {
+		t.Errorf("Expected an unknown command error, got: %v", err)
+	}
+}
+
+func TestCLI_WithHelpOutput(t *testing.T) {
+	var usage bytes.Buffer
+	cli.Usage(&usage, cliCommands)
+	for _, expected := range []string{"Usage: reconciliation-service <command>", "reconcile  reconcile the files", "validate   check the files"} {
+		if !strings.Contains(usage.String(), expected) {
+			t.Errorf("Expected the usage to contain %q, got: %s", expected, usage.String())
+		}
+	}
+
+	var help bytes.Buffer
+	flags := cli.NewFlagSet("reconcile", "[system.csv bank.csv YYYYMMDD YYYYMMDD]", "Reconciles the files.")
+	flags.SetOutput(&help)
+	var in cli.InputFlags
+	in.Register(flags)
+
+	if err := flags.Parse([]string{"--help"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Expected flag.ErrHelp, got: %v", err)
+	}
+	for _, expected := range []string{"Usage: reconciliation-service reconcile [flags] [system.csv", "Reconciles the files.", "-system files", "-period name", "-month month"} {
+		if !strings.Contains(help.String(), expected) {
+			t.Errorf("Expected the help to contain %q, got: %s", expected, help.String())
+		}
+	}
+}
+
+// parseInputs parses the input flags and arguments of a command line the way the commands reading files do
+func parseInputs(args []string) (cli.Inputs, error) {
+	flags := cli.NewFlagSet("reconcile", "", "")
+	flags.SetOutput(io.Discard)
+	var in cli.InputFlags
+	in.Register(flags)
+	if err := flags.Parse(args); err != nil {
+		return cli.Inputs{}, err
+	}
+	return in.Resolve(flags.Args(), time.Date(2025, time.July, 2, 10, 0, 0, 0, time.UTC))
+}
+
+func TestCLI_WithInputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected cli.Inputs
+	}{
+		{
+			"positional arguments",
+			[]string{"st.csv", "bankA_20250605.csv,bankB_20250605.csv", "20250601", "20250630"},
+			cli.Inputs{SystemFiles: []string{"st.csv"}, BankFiles: []string{"bankA_20250605.csv", "bankB_20250605.csv"}, Start: "20250601", End: "20250630", RangeSource: "arguments"},
+		},
+		{
+			"positional arguments without dates",
+			[]string{"st.csv", "bankA_20250607.csv,bankB_20250605.csv"},
+			cli.Inputs{SystemFiles: []string{"st.csv"}, BankFiles: []string{"bankA_20250607.csv", "bankB_20250605.csv"}, Start: "20250605", End: "20250607", RangeSource: "bank file names"},
+		},
+		{
+			"flags",
+			[]string{"--system", "st1.csv,st2.csv", "--bank", "bankA_20250605.csv", "--bank", "bankB_20250605.csv", "--start", "20250601", "--end", "20250630"},
+			cli.Inputs{SystemFiles: []string{"st1.csv", "st2.csv"}, BankFiles: []string{"bankA_20250605.csv", "bankB_20250605.csv"}, Start: "20250601", End: "20250630", RangeSource: "flags"},
+		},
+		{
+			"period",
+			[]string{"--system", "st.csv", "--bank", "bankA_20250605.csv", "--period", "yesterday"},
+			cli.Inputs{SystemFiles: []string{"st.csv"}, BankFiles: []string{"bankA_20250605.csv"}, Start: "20250701", End: "20250701", RangeSource: "--period yesterday"},
+		},
+		{
+			"last days",
+			[]string{"--system", "st.csv", "--bank", "bankA_20250605.csv", "--last", "7d"},
+			cli.Inputs{SystemFiles: []string{"st.csv"}, BankFiles: []string{"bankA_20250605.csv"}, Start: "20250625", End: "20250701", RangeSource: "--last 7d"},
+		},
+		{
+			"month",
+			[]string{"--system", "st.csv", "--bank", "bankA_20250605.csv", "--month", "2025-06"},
+			cli.Inputs{SystemFiles: []string{"st.csv"}, BankFiles: []string{"bankA_20250605.csv"}, Start: "20250601", End: "20250630", RangeSource: "--month 2025-06"},
+		},
+		{
+			"range of the bank file names",
+			[]string{"--system", "st.csv", "--bank", "bankA_20250607.csv,bankB_20250605.csv"},
+			cli.Inputs{SystemFiles: []string{"st.csv"}, BankFiles: []string{"bankA_20250607.csv", "bankB_20250605.csv"}, Start: "20250605", End: "20250607", RangeSource: "bank file names"},
+		},
+	}
+
+	for _, test := range tests {
+		inputs, err := parseInputs(test.args)
+		if err != nil {
+			t.Errorf("%s: Expected no error, got: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(inputs, test.expected) {
+			t.Errorf("%s: Expected %+v, got: %+v", test.name, test.expected, inputs)
+		}
+	}
+}
+
+func TestCLI_WithInvalidInputs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"positional arguments without dates in the file names", []string{"st.csv", "bankA.csv"}, "give the start and end dates"},
+		{"positional arguments with one date", []string{"st.csv", "bankA_20250605.csv", "20250601"}, "insufficient arguments"},
+		{"flags and positional arguments", []string{"--system", "st.csv", "bankA_20250605.csv"}, "unexpected arguments bankA_20250605.csv"},
+		{"missing bank files", []string{"--system", "st.csv", "--start", "20250601", "--end", "20250630"}, "missing --system or --bank files"},
+		{"two date ranges", []string{"--system", "st.csv", "--bank", "bankA_20250605.csv", "--month", "2025-06", "--last", "7d"}, "only one of"},
+		{"start after end", []string{"--system", "st.csv", "--bank", "bankA_20250605.csv", "--start", "20250630", "--end", "20250601"}, "end date cannot be earlier than start date"},
+		{"second system file", []string{"--system", "st1.csv,st2.txt", "--bank", "bankA_20250605.csv", "--month", "2025-06"}, "got st2.txt"},
+		{"unknown flag", []string{"--sytem", "st.csv"}, "flag provided but not defined"},
+	}
+
+	for _, test := range tests {
+		if _, err := parseInputs(test.args); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: Expected an error containing %q, got: %v", test.name, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sientong/reconciliation-service/config"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "reconciliation.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Expected no error writing the config file, got: %v", err)
+	}
+	return path
+}
+
+func environment(variables map[string]string) func(string) string {
+	return func(name string) string {
+		return variables[name]
+	}
+}
+
+func TestConfig_WithDefaults(t *testing.T) {
+	cfg, err := config.Load("", environment(map[string]string{config.FileVariable: ""}))
+	if err != nil {
+		t.Fatalf("Expected no error without config file, got: %v", err)
+	}
+
+	if cfg.File != "" || cfg.Strategy != "concurrent" || cfg.Output.Format != "text" || cfg.BalanceTolerance != 0.005 {
+		t.Errorf("Expected the default settings, got: %+v", cfg)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected the default settings to be valid, got: %v", err)
+	}
+}
+
+func TestConfig_WithLayers(t *testing.T) {
+	path := writeConfigFile(t, `
+strategy: simple
+workers: 4
+balance_tolerance: 0.01
+banks:
+  bankA:
+    amount_format: id
+  bankB:
+    amount_format: auto
+output:
+  format: json
+data_quality:
+  per_file:
+    max_rejected_pct: 10
+    min_rows: 5
+`)
+
+	cfg, err := config.Load(path, environment(map[string]string{
+		"RECONCILLIATION_STRATEGY": "indexed",
+		"BANK_AMOUNT_FORMATS":      "bankB:standard",
+		"DQ_MAX_REJECTED_PCT":      "20",
+		"OUTPUT_FORMAT":            "",
+		"BALANCE_TOLERANCE":        "0.5",
+		"HEADER_ALIASES":           "date:value_date|posting_date",
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error loading the config file, got: %v", err)
+	}
+
+	if cfg.File != path {
+		t.Errorf("Expected the config file %s, got: %s", path, cfg.File)
+	}
+
+	if cfg.Strategy != "indexed" || cfg.Workers != 4 || cfg.Output.Format != "json" {
+		t.Errorf("Expected the environment over the file and the file over the defaults, got: %+v", cfg)
+	}
+
+	if cfg.BalanceTolerance != 0.5 {
+		t.Errorf("Expected the balance tolerance from the environment, got: %g", cfg.BalanceTolerance)
+	}
+
+	if aliases := cfg.Headers.Aliases["date"]; len(aliases) != 2 || aliases[1] != "posting_date" {
+		t.Errorf("Expected the date aliases from the environment, got: %v", cfg.Headers.Aliases)
+	}
+
+	if cfg.Banks["bankA"].AmountFormat != "id" || cfg.Banks["bankB"].AmountFormat != "standard" {
+		t.Errorf("Expected bankA from the file and bankB from the environment, got: %+v", cfg.Banks)
+	}
+
+	quality := cfg.DataQuality.PerFile
+	if quality.MaxRejectedPct == nil || *quality.MaxRejectedPct != 20 || quality.MinRowCount == nil || *quality.MinRowCount != 5 {
+		t.Errorf("Expected max_rejected_pct from the environment and min_rows from the file, got: %+v", quality)
+	}
+}
+
+func TestConfig_WithFileFromEnvironment(t *testing.T) {
+	path := writeConfigFile(t, "workers: 2\n")
+
+	cfg, err := config.Load("", environment(map[string]string{config.FileVariable: path}))
+	if err != nil {
+		t.Fatalf("Expected no error loading the config file, got: %v", err)
+	}
+
+	if cfg.File != path || cfg.Workers != 2 {
+		t.Errorf("Expected the file named by %s, got: %+v", config.FileVariable, cfg)
+	}
+
+	if _, err := config.Load(filepath.Join(t.TempDir(), "missing.yaml"), environment(nil)); err == nil {
+		t.Errorf("Expected an error for a missing config file, got nil")
+	}
+}
+
+func TestConfig_WithUnknownKey(t *testing.T) {
+	path := writeConfigFile(t, "strategy: simple\nstrategie: indexed\n")
+
+	if _, err := config.Load(path, environment(nil)); err == nil {
+		t.Errorf("Expected an error for an unknown key, got nil")
+	}
+}
+
+func TestConfig_WithInvalidSettings(t *testing.T) {
+	path := writeConfigFile(t, `
+strategy: fastest
+workers: -1
+balance_tolerance: -0.01
+banks:
+  bankA:
+    amount_format: roman
+headers:
+  aliases:
+    valueDate: [value_date]
+output:
+  format: xml
+aging:
+  buckets: 0-1,5-7
+`)
+
+	cfg, err := config.Load(path, environment(nil))
+	if err != nil {
+		t.Fatalf("Expected no error loading the config file, got: %v", err)
+	}
+
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatalf("Expected an error for invalid settings, got nil")
+	}
+
+	for _, expected := range []string{"strategy fastest", "workers -1", "amount format roman", "unknown column valueDate", "output format xml", "aging bucket", "balance_tolerance -0.01"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected the error to mention %q, got: %v", expected, err)
+		}
+	}
+}
+
+func TestConfig_WithEffectiveSettingsWritten(t *testing.T) {
+	cfg, err := config.Load(writeConfigFile(t, "strategy: simple\ndiscrepancy:\n  max_unmatched: 3\n"), environment(nil))
+	if err != nil {
+		t.Fatalf("Expected no error loading the config file, got: %v", err)
+	}
+
+	var sb strings.Builder
+	if err := cfg.Write(&sb); err != nil {
+		t.Fatalf("Expected no error writing the settings, got: %v", err)
+	}
+
+	reloaded, err := config.Load(writeConfigFile(t, sb.String()), environment(nil))
+	if err != nil {
+		t.Fatalf("Expected the written settings to be a valid config file, got: %v", err)
+	}
+
+	if reloaded.Strategy != "simple" || reloaded.Discrepancy.MaxUnmatched == nil || *reloaded.Discrepancy.MaxUnmatched != 3 {
+		t.Errorf("Expected the written settings to load back, got: %+v", reloaded)
+	}
+}
+
+func TestConfig_WithInvalidEnvironment(t *testing.T) {
+	for name, value := range map[string]string{
+		"BANK_AMOUNT_FORMATS":    "bankA",
+		"HEADER_ALIASES":         "date",
+		"RECONCILIATION_WORKERS": "many",
+		"BALANCE_TOLERANCE":      "half a cent",
+		"HEADER_CASE_SENSITIVE":  "yes",
+	} {
+		if _, err := config.Load("", environment(map[string]string{name: value})); err == nil {
+			t.Errorf("Expected an error for %s=%s, got nil", name, value)
+		}
+	}
+}
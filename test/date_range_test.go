@@ -0,0 +1,92 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/sientong/reconciliation-service/validator"
+)
+
+var rangeToday = time.Date(2025, time.March, 1, 9, 30, 0, 0, time.UTC)
+
+func TestDateRange_WithPeriods(t *testing.T) {
+	expected := map[string][2]string{
+		"today":      {"20250301", "20250301"},
+		"yesterday":  {"20250228", "20250228"},
+		"this-month": {"20250301", "20250301"},
+		"last-month": {"20250201", "20250228"},
+	}
+
+	for period, dates := range expected {
+		start, end, err := PeriodRange(period, rangeToday)
+		if err != nil {
+			t.Errorf("Expected no error for period %s, got: %v", period, err)
+		}
+		if start != dates[0] || end != dates[1] {
+			t.Errorf("Expected %s to be %s - %s, got: %s - %s", period, dates[0], dates[1], start, end)
+		}
+	}
+
+	if _, _, err := PeriodRange("last-week", rangeToday); err == nil {
+		t.Errorf("Expected an error for an unknown period, got nil")
+	}
+}
+
+func TestDateRange_WithLast(t *testing.T) {
+	expected := map[string][2]string{
+		"1d": {"20250228", "20250228"},
+		"7d": {"20250222", "20250228"},
+		"2w": {"20250215", "20250228"},
+	}
+
+	for last, dates := range expected {
+		start, end, err := LastRange(last, rangeToday)
+		if err != nil {
+			t.Errorf("Expected no error for --last %s, got: %v", last, err)
+		}
+		if start != dates[0] || end != dates[1] {
+			t.Errorf("Expected --last %s to be %s - %s, got: %s - %s", last, dates[0], dates[1], start, end)
+		}
+	}
+
+	for _, last := range []string{"7", "0d", "-3d", "d", "1m"} {
+		if _, _, err := LastRange(last, rangeToday); err == nil {
+			t.Errorf("Expected an error for --last %s, got nil", last)
+		}
+	}
+}
+
+func TestDateRange_WithMonth(t *testing.T) {
+	start, end, err := MonthRange("2024-02")
+	if err != nil {
+		t.Fatalf("Expected no error for a month, got: %v", err)
+	}
+	if start != "20240201" || end != "20240229" {
+		t.Errorf("Expected 20240201 - 20240229, got: %s - %s", start, end)
+	}
+
+	if _, _, err := MonthRange("202402"); err == nil {
+		t.Errorf("Expected an error for a month not in YYYY-MM, got nil")
+	}
+}
+
+func TestDateRange_InferredFromBankFiles(t *testing.T) {
+	start, end, err := InferDateRange([]string{"csv/bankB_20250607.csv", "bankA_20250605_resent.csv", "/data/bankA_20250606.csv"})
+	if err != nil {
+		t.Fatalf("Expected no error inferring the range, got: %v", err)
+	}
+	if start != "20250605" || end != "20250607" {
+		t.Errorf("Expected 20250605 - 20250607, got: %s - %s", start, end)
+	}
+
+	// The inferred range goes through the same checks as a given one
+	if err := ValidateArgs([]string{"system.csv", "bankA_20250605.csv", start, end}); err != nil {
+		t.Errorf("Expected the inferred range to be valid, got: %v", err)
+	}
+
+	for _, bankFile := range []string{"balances.csv", "bankA_June.csv", "bankA_20251305.csv"} {
+		if _, _, err := InferDateRange([]string{bankFile}); err == nil {
+			t.Errorf("Expected an error inferring the range from %s, got nil", bankFile)
+		}
+	}
+}
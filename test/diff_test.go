@@ -0,0 +1,162 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/report"
+)
+
+// storeResults reconciles the files and stores the results as json, the way a run with --format json does
+func storeResults(t *testing.T, filePath string, bankFiles []string) {
+	clearRecords()
+
+	if err := CreateRecords("../csv/st_small.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := CreateBankStatementRecords(bankFiles, "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		t.Fatalf("Expected no error creating %s, but got: %v", filePath, err)
+	}
+	defer file.Close()
+
+	if err := report.WriteJSON(file, output); err != nil {
+		t.Fatalf("Expected no error writing the results, but got: %v", err)
+	}
+
+	clearRecords()
+}
+
+func TestDiff_WithResentBankFile(t *testing.T) {
+
+	dir := t.TempDir()
+
+	// bankA resends its statement with BA0003 corrected to the amount of TX0011
+	resent := filepath.Join(dir, "bankA_20250605.csv")
+	os.WriteFile(resent, []byte("unique_identifier,amount,date\n"+
+		"BA0001,-6241250.16,2025-06-05\n"+
+		"BA0002,3935387.85,2025-06-05\n"+
+		"BA0003,5943210.24,2025-06-05\n"), 0o644)
+
+	storeResults(t, filepath.Join(dir, "previous.json"), []string{"../csv/bankA_20250605.csv", "../csv/bankB_20250605.csv"})
+	storeResults(t, filepath.Join(dir, "current.json"), []string{resent, "../csv/bankB_20250605.csv"})
+
+	previous, err := report.ReadJSON(filepath.Join(dir, "previous.json"))
+	if err != nil {
+		t.Fatalf("Expected no error reading the previous results, but got: %v", err)
+	}
+	current, err := report.ReadJSON(filepath.Join(dir, "current.json"))
+	if err != nil {
+		t.Fatalf("Expected no error reading the current results, but got: %v", err)
+	}
+
+	diff := DiffResults(previous, current)
+
+	if len(diff.NewlyMatched) != 2 {
+		t.Fatalf("Expected TX0011 and BA0003 to be newly matched, got: %+v", diff.NewlyMatched)
+	}
+
+	system, bank := diff.NewlyMatched[0], diff.NewlyMatched[1]
+	if system.Side != model.SideSystem || system.Identifier != "TX0011" || system.Pair == nil || system.Pair.Bank.UniqueIdentifier != "BA0003" {
+		t.Errorf("Expected TX0011 to be matched to BA0003, got: %+v", system)
+	}
+	if bank.Side != model.SideBank || bank.BankName != "bankA" || bank.Identifier != "BA0003" || bank.Pair == nil || bank.Pair.System.TrxID != "TX0011" {
+		t.Errorf("Expected bankA BA0003 to be matched to TX0011, got: %+v", bank)
+	}
+
+	if len(diff.NewlyUnmatched) != 0 || len(diff.Removed) != 0 || len(diff.Repaired) != 0 {
+		t.Errorf("Expected no newly unmatched, removed or re-paired items, got: %+v", diff)
+	}
+
+	changed := make(map[string]model.TotalChange)
+	for _, total := range diff.Totals {
+		changed[total.Name] = total
+	}
+	if changed["total_matched_transactions"].Delta != 1 || changed["total_unmatched_transactions"].Delta != -2 {
+		t.Errorf("Expected 1 more matched and 2 fewer unmatched items, got: %+v", diff.Totals)
+	}
+	if _, exists := changed["total_invalid_records"]; exists {
+		t.Errorf("Expected only the totals which changed, got: %+v", diff.Totals)
+	}
+}
+
+func TestDiff_WithRepairedTransaction(t *testing.T) {
+
+	pair := func(trxID string, bankName string, uniqueIdentifier string) model.MatchedPair {
+		return model.MatchedPair{
+			System:   model.InternalTransactionRecord{TrxID: trxID, Amount: 100, Type: model.DirectionCredit, TransactionTime: "2025-06-05T08:00:00Z"},
+			BankName: bankName,
+			Bank:     model.BankStatementRecord{UniqueIdentifier: uniqueIdentifier, Amount: 100, Direction: model.DirectionCredit, Date: "2025-06-05"},
+		}
+	}
+
+	previous := &model.Output{
+		MatchedPairs:    []model.MatchedPair{pair("TX0001", "bankA", "BA0001"), pair("TX0002", "bankA", "BA0002")},
+		RejectedRecords: []model.RejectedRecord{{SourceFile: "bankB_20250605.csv", Line: 4, Raw: "BB0004,abc,2025-06-05", Reason: model.ReasonBadAmount}},
+	}
+	current := &model.Output{
+		MatchedPairs:    []model.MatchedPair{pair("TX0001", "bankB", "BB0001"), pair("TX0002", "bankA", "BA0002")},
+		RejectedRecords: []model.RejectedRecord{{SourceFile: "bankB_20250605_resent.csv", Line: 4, Raw: "BB0004,abc,2025-06-05", Reason: model.ReasonBadAmount}},
+	}
+
+	diff := DiffResults(previous, current)
+
+	if len(diff.Repaired) != 1 || diff.Repaired[0].TrxID != "TX0001" || diff.Repaired[0].Current.BankName != "bankB" {
+		t.Errorf("Expected TX0001 to be re-paired to bankB, got: %+v", diff.Repaired)
+	}
+
+	// The same row in a resent file is not a change
+	if len(diff.RejectedAdded) != 0 || len(diff.RejectedRemoved) != 0 {
+		t.Errorf("Expected no rejected row changes, got: %+v and %+v", diff.RejectedAdded, diff.RejectedRemoved)
+	}
+
+	if !DiffResults(current, current).Empty() {
+		t.Errorf("Expected no changes between the same results")
+	}
+}
+
+func TestDiff_WithUnsupportedSchemaVersion(t *testing.T) {
+
+	filePath := filepath.Join(t.TempDir(), "results.json")
+	os.WriteFile(filePath, []byte(`{"schema_version": "0", "total_processed_records": 1}`), 0o644)
+
+	_, err := report.ReadJSON(filePath)
+	if err == nil || !strings.Contains(err.Error(), `schema version "0" is not supported`) {
+		t.Errorf("Expected an unsupported schema version error, got: %v", err)
+	}
+}
+
+func TestDiff_WithTotalsAddedInAnotherOrder(t *testing.T) {
+
+	// The same amounts summed in another order, e.g. by the workers of a concurrent strategy
+	amounts := []float64{0.1, 0.2, 0.3}
+	previous := &model.Output{TotalDiscrepancies: amounts[0] + amounts[1] + amounts[2]}
+	current := &model.Output{TotalDiscrepancies: amounts[2] + amounts[1] + amounts[0]}
+
+	if previous.TotalDiscrepancies == current.TotalDiscrepancies {
+		t.Fatalf("Expected the sums to differ in their last digits")
+	}
+
+	if diff := DiffResults(previous, current); !diff.Empty() {
+		t.Errorf("Expected no changes for totals differing by less than a cent, got: %+v", diff.Totals)
+	}
+
+	current.TotalDiscrepancies += 0.01
+	if diff := DiffResults(previous, current); len(diff.Totals) != 1 || diff.Totals[0].Name != "total_discrepancies" {
+		t.Errorf("Expected total_discrepancies to change by a cent, got: %+v", diff.Totals)
+	}
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	. "github.com/sientong/reconciliation-service/validator"
@@ -26,7 +27,7 @@ func TestFile_WithIncorrectNumberHeaderColumn(t *testing.T) {
 		t.Errorf("Expected error for incorrect header, but got nil")
 	}
 
-	expectedMessage := fmt.Sprintf("invalid header in %s: expected 4 columns, got 3", filepath)
+	expectedMessage := fmt.Sprintf("invalid header in %s: missing column trxID", filepath)
 	if err.Error() != expectedMessage {
 		t.Errorf("Expected error message '%s', but got '%s'", expectedMessage, err.Error())
 	}
@@ -39,7 +40,7 @@ func TestFile_WithIncorrectHeaderValue(t *testing.T) {
 		t.Errorf("Expected error for incorrect header, but got nil")
 	}
 
-	expectedMessage := fmt.Sprintf("invalid header in %s: expected column trxID, got transactionID", filepath)
+	expectedMessage := fmt.Sprintf("invalid header in %s: missing column trxID, unknown columns: transactionID", filepath)
 	if err.Error() != expectedMessage {
 		t.Errorf("Expected error message '%s', but got '%s'", expectedMessage, err.Error())
 	}
@@ -60,3 +61,77 @@ func TestFile_WithValidBankStatementFile(t *testing.T) {
 		t.Errorf("Expected no error for valid bank statement file, but got: %v", err)
 	}
 }
+
+func TestFile_WithDebitCreditBankStatementFile(t *testing.T) {
+	filepath := "../csv/bankC_20250605.csv"
+	err := ValidateFile(filepath, "bankStatement")
+	if err != nil {
+		t.Errorf("Expected no error for debit/credit bank statement file, but got: %v", err)
+	}
+}
+
+func TestFile_WithReorderedAliasedAndExtraColumns(t *testing.T) {
+	if err := ValidateFile("../csv/st_aliases.csv", "systemTransaction"); err != nil {
+		t.Errorf("Expected no error for aliased system transaction columns, but got: %v", err)
+	}
+
+	if err := ValidateFile("../csv/bankF_20250605_extra.csv", "bankStatement"); err != nil {
+		t.Errorf("Expected no error for bank statement with extra columns, but got: %v", err)
+	}
+}
+
+func TestFile_WithCaseSensitiveHeader(t *testing.T) {
+	HeaderCaseSensitive = true
+	defer func() { HeaderCaseSensitive = false }()
+
+	filepath := "../csv/bankF_20250605_extra.csv"
+	err := ValidateFile(filepath, "bankStatement")
+	if err == nil {
+		t.Fatalf("Expected error for header written in another case, but got nil")
+	}
+
+	expectedMessage := fmt.Sprintf("invalid header in %s: missing column unique_identifier, unknown columns: Date, Description, Unique_ID, Amount, Balance", filepath)
+	if err.Error() != expectedMessage {
+		t.Errorf("Expected error message '%s', but got '%s'", expectedMessage, err.Error())
+	}
+}
+
+func TestFile_WithDuplicateColumn(t *testing.T) {
+	_, err := MapHeader([]string{"trxID", "amount", "type", "transactionTime", "trx_id"}, "systemTransaction")
+	if err == nil || err.Error() != "column trxID appears more than once" {
+		t.Errorf("Expected error for a column given twice, but got: %v", err)
+	}
+}
+
+func TestFile_WithRepeatedExtraColumn(t *testing.T) {
+	header, err := MapHeader([]string{"trxID", "amount", "note", "type", "transactionTime", "note", "note"}, "systemTransaction")
+	if err != nil {
+		t.Fatalf("Expected no error for repeated extra columns, but got: %v", err)
+	}
+
+	expected := map[string]int{"note": 2, "note#2": 5, "note#3": 6}
+	if !reflect.DeepEqual(header.Extra, expected) {
+		t.Errorf("Expected every note column to be kept, got: %v", header.Extra)
+	}
+}
+
+func TestFile_WithConfiguredColumnAlias(t *testing.T) {
+	defer delete(ColumnAliases, "date")
+
+	if err := AddColumnAliases("date", []string{"value_date", "posting_date"}); err != nil {
+		t.Fatalf("Expected no error configuring aliases, but got: %v", err)
+	}
+
+	header, err := MapHeader([]string{"posting_date", "unique_identifier", "amount"}, "bankStatement")
+	if err != nil {
+		t.Fatalf("Expected no error for aliased date column, but got: %v", err)
+	}
+
+	if header.Columns["date"] != 0 || header.Columns["unique_identifier"] != 1 || header.Layout != BankLayoutSignedAmount {
+		t.Errorf("Expected date at 0 and unique_identifier at 1 in the signed amount layout, got: %+v", header)
+	}
+
+	if err := AddColumnAliases("valueDate", []string{"value_date"}); err == nil {
+		t.Errorf("Expected error for an alias of an unknown column, but got nil")
+	}
+}
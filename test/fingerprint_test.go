@@ -0,0 +1,124 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+)
+
+func copyFile(t *testing.T, source string, target string) string {
+	content, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatalf("Expected no error reading %s, but got: %v", source, err)
+	}
+
+	if err := os.WriteFile(target, content, 0o644); err != nil {
+		t.Fatalf("Expected no error writing %s, but got: %v", target, err)
+	}
+	return target
+}
+
+func TestFingerprint_WithSameFileUnderAnotherName(t *testing.T) {
+
+	clearRecords()
+
+	renamed := copyFile(t, "../csv/bankA_20250605.csv", filepath.Join(t.TempDir(), "bankA_20250605_resent.csv"))
+
+	if err := CreateBankStatementRecords([]string{"../csv/bankA_20250605.csv", renamed}, "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.BankStatementRecordsMap["bankA"]) != 3 {
+		t.Errorf("Expected the records of bankA to be loaded once, got: %d", len(model.BankStatementRecordsMap["bankA"]))
+	}
+
+	if len(model.LoadedFiles) != 1 || len(model.BalanceChecks) != 1 {
+		t.Errorf("Expected only the first file to be profiled and checked, got: %d profiles and %d balance checks", len(model.LoadedFiles), len(model.BalanceChecks))
+	}
+
+	if len(model.Run.Ingestion) != 2 {
+		t.Fatalf("Expected 2 ingestion decisions, got: %d", len(model.Run.Ingestion))
+	}
+
+	if model.Run.Ingestion[0].Decision != model.IngestionLoaded {
+		t.Errorf("Expected the first file to be loaded, got: %+v", model.Run.Ingestion[0])
+	}
+
+	refused := model.Run.Ingestion[1]
+	if refused.Decision != model.IngestionRefused || refused.DuplicateOf != "../csv/bankA_20250605.csv" {
+		t.Errorf("Expected the renamed file to be refused as a duplicate of bankA_20250605.csv, got: %+v", refused)
+	}
+
+	if refused.ContentHash != model.Run.Ingestion[0].ContentHash {
+		t.Errorf("Expected both files to have the same content hash, got: %s and %s", model.Run.Ingestion[0].ContentHash, refused.ContentHash)
+	}
+
+	clearRecords()
+}
+
+func TestFingerprint_WithOverlappingRows(t *testing.T) {
+
+	clearRecords()
+
+	if err := CreateBankStatementRecords([]string{"../csv/bankA_20250605.csv", "../csv/bankA_20250605_large.csv"}, "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.BankStatementRecordsMap["bankA"]) != 51 {
+		t.Errorf("Expected 51 bankA records, got: %d", len(model.BankStatementRecordsMap["bankA"]))
+	}
+
+	decision := model.Run.Ingestion[1]
+	if decision.Decision != model.IngestionDeduplicated || decision.DuplicateRows != 2 {
+		t.Errorf("Expected 2 rows to be deduplicated, got: %+v", decision)
+	}
+
+	if model.LoadedFiles[1].DuplicateRows != 2 || model.LoadedFiles[1].AcceptedRows != 48 {
+		t.Errorf("Expected 48 accepted and 2 duplicate rows, got: %d accepted and %d duplicate", model.LoadedFiles[1].AcceptedRows, model.LoadedFiles[1].DuplicateRows)
+	}
+
+	clearRecords()
+}
+
+func TestFingerprint_WithPersistedStore(t *testing.T) {
+
+	clearRecords()
+
+	store := filepath.Join(t.TempDir(), "fingerprints.json")
+
+	if err := LoadFingerprintStore(store); err != nil {
+		t.Errorf("Expected a missing store to be empty, but got: %v", err)
+	}
+
+	if err := CreateRecords("../csv/st_small.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := SaveFingerprintStore(store); err != nil {
+		t.Fatalf("Expected no error saving the store, but got: %v", err)
+	}
+
+	// Next run
+	clearRecords()
+
+	if err := LoadFingerprintStore(store); err != nil {
+		t.Fatalf("Expected no error loading the store, but got: %v", err)
+	}
+
+	if err := CreateRecords("../csv/st_small.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.SystemTransactionRecords) != 0 {
+		t.Errorf("Expected a file loaded in a previous run to be refused, got: %d records", len(model.SystemTransactionRecords))
+	}
+
+	if len(model.Run.Ingestion) != 1 || model.Run.Ingestion[0].Decision != model.IngestionRefused {
+		t.Errorf("Expected the file to be refused, got: %+v", model.Run.Ingestion)
+	}
+
+	clearRecords()
+}
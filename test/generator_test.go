@@ -0,0 +1,115 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/sientong/reconciliation-service/generator"
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+)
+
+func generatorSettings() generator.Settings {
+	return generator.Settings{
+		Transactions:  500,
+		Banks:         3,
+		StartDate:     "20250601",
+		Days:          3,
+		MatchRate:     0.9,
+		Lags:          []generator.Lag{{Days: 0, Weight: 80}, {Days: 1, Weight: 15}, {Days: 2, Weight: 5}},
+		FeeRate:       0.05,
+		SplitRate:     0.05,
+		DuplicateRate: 0.02,
+		MalformedRate: 0.02,
+		BankOnlyRate:  0.03,
+		Seed:          7,
+	}
+}
+
+// reconcileDataset loads the generated files and reconciles them with a strategy
+func reconcileDataset(t *testing.T, dataset *generator.Dataset, reconcile func() (*model.Output, error)) *model.Output {
+	clearRecords()
+
+	if err := CreateRecords(dataset.SystemFile, "systemTransaction", dataset.StartDate, dataset.EndDate); err != nil {
+		t.Fatalf("Expected no error loading the system file, got: %v", err)
+	}
+	if err := CreateBankStatementRecords(dataset.BankFiles, dataset.StartDate, dataset.EndDate); err != nil {
+		t.Fatalf("Expected no error loading the bank files, got: %v", err)
+	}
+
+	output, err := reconcile()
+	if err != nil {
+		t.Fatalf("Expected no error reconciling, got: %v", err)
+	}
+	return output
+}
+
+func TestGenerator_WithExpectedResults(t *testing.T) {
+	dataset, err := generator.Generate(t.TempDir(), generatorSettings())
+	if err != nil {
+		t.Fatalf("Expected no error generating the dataset, got: %v", err)
+	}
+
+	if len(dataset.BankFiles) < 3 {
+		t.Errorf("Expected bank files for several banks and days, got: %v", dataset.BankFiles)
+	}
+
+	scenarios := make(map[string]int)
+	for _, settlement := range dataset.Settlements {
+		scenarios[settlement.Scenario]++
+	}
+	for _, scenario := range []string{generator.ScenarioExact, generator.ScenarioLagged, generator.ScenarioFee, generator.ScenarioSplit, generator.ScenarioUnsettled, generator.ScenarioBankOnly} {
+		if scenarios[scenario] == 0 {
+			t.Errorf("Expected %s settlements, got: %v", scenario, scenarios)
+		}
+	}
+
+	expected := dataset.Expected
+	if expected.TotalMatchedTransactions == 0 || expected.TotalUnmatchedBankStmts == 0 || len(expected.RejectedRecords) == 0 {
+		t.Errorf("Expected matched, unmatched and rejected rows, got: %d matched, %d unmatched bank statements, %d rejected",
+			expected.TotalMatchedTransactions, expected.TotalUnmatchedBankStmts, len(expected.RejectedRecords))
+	}
+
+	strategies := map[string]func() (*model.Output, error){
+		"simple":     SimpleReconciliation,
+		"concurrent": ConcurrentReconcilliation,
+		"indexed":    ConcurrentReconciliationIndexed,
+	}
+	for name, reconcile := range strategies {
+		output := reconcileDataset(t, dataset, reconcile)
+
+		if diff := DiffResults(expected, output); !diff.Empty() {
+			t.Errorf("Expected the %s strategy to give the expected results, got: %d newly matched, %d newly unmatched, %d removed, %d re-paired, %d rejected added, %d rejected removed, totals %+v",
+				name, len(diff.NewlyMatched), len(diff.NewlyUnmatched), len(diff.Removed), len(diff.Repaired), len(diff.RejectedAdded), len(diff.RejectedRemoved), diff.Totals)
+		}
+	}
+
+	clearRecords()
+}
+
+func TestGenerator_WithSameSeed(t *testing.T) {
+	first, err := generator.Generate(t.TempDir(), generatorSettings())
+	if err != nil {
+		t.Fatalf("Expected no error generating the dataset, got: %v", err)
+	}
+	second, err := generator.Generate(t.TempDir(), generatorSettings())
+	if err != nil {
+		t.Fatalf("Expected no error generating the dataset, got: %v", err)
+	}
+
+	if !DiffResults(first.Expected, second.Expected).Empty() || first.EndDate != second.EndDate {
+		t.Errorf("Expected the same seed to generate the same dataset")
+	}
+}
+
+func TestGenerator_WithInvalidSettings(t *testing.T) {
+	settings := generatorSettings()
+	settings.FeeRate, settings.SplitRate = 0.6, 0.6
+
+	if _, err := generator.Generate(t.TempDir(), settings); err == nil {
+		t.Errorf("Expected an error for fee and split rates above 1, got nil")
+	}
+
+	if _, err := generator.ParseLags("0:80,x:20"); err == nil {
+		t.Errorf("Expected an error for an invalid lag, got nil")
+	}
+}
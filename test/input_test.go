@@ -0,0 +1,192 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/util"
+	. "github.com/sientong/reconciliation-service/validator"
+)
+
+const bankStatementContent = "unique_identifier,amount,date\nBS0001,-1500.50,2025-06-05\nBS0002,2000.00,2025-06-05\n"
+
+func writeInputFile(t *testing.T, name string, content []byte) string {
+	filePath := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filePath, content, 0o644); err != nil {
+		t.Fatalf("Expected no error writing %s, but got: %v", filePath, err)
+	}
+	return filePath
+}
+
+func utf16LE(text string) []byte {
+	content := []byte{0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		content = append(content, byte(unit), byte(unit>>8))
+	}
+	return content
+}
+
+func TestInput_WithBankStatementDialects(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		content   []byte
+		encoding  string
+		delimiter string
+	}{
+		{"comma", []byte(bankStatementContent), model.EncodingUTF8, ","},
+		{"utf-8 bom and quoted header", append([]byte{0xEF, 0xBB, 0xBF}, []byte("\"unique_identifier\",\"amount\",\"date\"\nBS0001,-1500.50,2025-06-05\nBS0002,2000.00,2025-06-05\n")...), model.EncodingUTF8, ","},
+		{"semicolon", []byte("unique_identifier;amount;date\r\nBS0001;-1500.50;2025-06-05\r\nBS0002;2000.00;2025-06-05\r\n"), model.EncodingUTF8, ";"},
+		{"pipe", []byte("unique_identifier|amount|date\nBS0001|-1500.50|2025-06-05\nBS0002|2000.00|2025-06-05\n"), model.EncodingUTF8, "|"},
+		{"utf-16 from excel", utf16LE("unique_identifier\tamount\tdate\r\nBS0001\t-1500.50\t2025-06-05\r\nBS0002\t2000.00\t2025-06-05\r\n"), model.EncodingUTF16LE, "\t"},
+	}
+
+	for _, test := range tests {
+		clearRecords()
+
+		filePath := writeInputFile(t, "bankS_20250605.csv", test.content)
+
+		if err := ValidateFile(filePath, "bankStatement"); err != nil {
+			t.Errorf("%s: Expected no error validating the header, but got: %v", test.name, err)
+			continue
+		}
+
+		if err := CreateRecords(filePath, "bankStatement", "20250601", "20250630"); err != nil {
+			t.Errorf("%s: Expected no error for valid record, but got: %v", test.name, err)
+		}
+
+		records := model.BankStatementRecordsMap["bankS"]
+		if len(records) != 2 || len(model.RejectedRecords) != 0 {
+			t.Errorf("%s: Expected 2 records and no rejected records, got: %d records and %d rejected", test.name, len(records), len(model.RejectedRecords))
+			continue
+		}
+
+		if records[0].UniqueIdentifier != "BS0001" || records[0].Amount != -1500.50 || records[1].Date != "2025-06-05" {
+			t.Errorf("%s: Expected the rows to be read as written, got: %+v and %+v", test.name, *records[0], *records[1])
+		}
+
+		dialect := model.LoadedFiles[0].Dialect
+		if dialect.Encoding != test.encoding || dialect.Delimiter != test.delimiter {
+			t.Errorf("%s: Expected %s with delimiter %q, got: %s with delimiter %q", test.name, test.encoding, test.delimiter, dialect.Encoding, dialect.Delimiter)
+		}
+	}
+
+	clearRecords()
+}
+
+func TestInput_WithUnpairedSurrogate(t *testing.T) {
+
+	// A high surrogate followed by a comma, a lone low surrogate before a newline, and a valid pair
+	content := []byte{0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune("unique_identifier,amount,date\nBS")) {
+		content = append(content, byte(unit), byte(unit>>8))
+	}
+	content = append(content, 0x00, 0xD8)
+	for _, unit := range utf16.Encode([]rune(",-1500.50,2025-06-05")) {
+		content = append(content, byte(unit), byte(unit>>8))
+	}
+	content = append(content, 0x00, 0xDC)
+	content = append(content, utf16LE("\nBS😀,2000.00,2025-06-05\n")[2:]...)
+
+	file, err := util.OpenCSV(writeInputFile(t, "bankU_20250605.csv", content))
+	if err != nil {
+		t.Fatalf("Expected no error opening the file, but got: %v", err)
+	}
+	defer file.Close()
+
+	rows, err := file.ReadAll()
+	if err != nil {
+		t.Fatalf("Expected no error reading the file, but got: %v", err)
+	}
+
+	expected := [][]string{
+		{"unique_identifier", "amount", "date"},
+		{"BS\uFFFD", "-1500.50", "2025-06-05\uFFFD"},
+		{"BS😀", "2000.00", "2025-06-05"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected the unpaired surrogates to be replaced and the rows kept, got: %q", rows)
+	}
+}
+
+func TestInput_WithWindows1252(t *testing.T) {
+
+	// 0x80 is the euro sign and 0xE9 is é in Windows-1252
+	encoding, bom := util.DetectEncoding([]byte("unique_identifier,amount,date\nCAF\xe9,\x8010,2025-06-05\n"))
+	if encoding != model.EncodingWindows1252 || bom {
+		t.Errorf("Expected windows-1252 without BOM, got: %s, %v", encoding, bom)
+	}
+
+	filePath := writeInputFile(t, "bankW_20250605.csv", []byte("unique_identifier,amount,date\nCAF\xe9,\x8010.00,2025-06-05\n"))
+
+	file, err := util.OpenCSV(filePath)
+	if err != nil {
+		t.Fatalf("Expected no error opening the file, but got: %v", err)
+	}
+	defer file.Close()
+
+	rows, err := file.ReadAll()
+	if err != nil {
+		t.Fatalf("Expected no error reading the file, but got: %v", err)
+	}
+
+	if len(rows) != 2 || rows[1][0] != "CAFé" || rows[1][1] != "€10.00" {
+		t.Errorf("Expected the row to be decoded to utf-8, got: %q", rows)
+	}
+}
+
+func TestInput_WithUTF8SplitAtSampleEnd(t *testing.T) {
+
+	// A sample cut in the middle of é is still utf-8
+	encoding, _ := util.DetectEncoding([]byte("unique_identifier,amount,date\nCAF\xc3"))
+	if encoding != model.EncodingUTF8 {
+		t.Errorf("Expected utf-8, got: %s", encoding)
+	}
+}
+
+func TestInput_WithDelimiterInsideQuotes(t *testing.T) {
+
+	delimiter, quoted := util.DetectDelimiter([]byte("\"id;name\",amount,date\n"))
+	if delimiter != ',' || !quoted {
+		t.Errorf("Expected comma and a quoted header, got: %q and %v", delimiter, quoted)
+	}
+}
+
+func TestInput_WithLargeUTF16File(t *testing.T) {
+
+	clearRecords()
+
+	content, err := os.ReadFile("../csv/system_transactions_large.csv")
+	if err != nil {
+		t.Fatalf("Expected no error reading the file, but got: %v", err)
+	}
+
+	if err := CreateRecords("../csv/system_transactions_large.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Fatalf("Expected no error for valid record, but got: %v", err)
+	}
+	expected := model.SystemTransactionRecords
+
+	clearRecords()
+
+	filePath := writeInputFile(t, "system_transactions_utf16.csv", utf16LE(string(content)))
+	if err := CreateRecords(filePath, "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Fatalf("Expected no error for valid record, but got: %v", err)
+	}
+
+	records := model.SystemTransactionRecords
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got: %d", len(expected), len(records))
+	}
+	for i := range records {
+		if !reflect.DeepEqual(*records[i], *expected[i]) {
+			t.Fatalf("Expected record %d to be %+v, got: %+v", i, *expected[i], *records[i])
+		}
+	}
+
+	clearRecords()
+}
@@ -0,0 +1,191 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/report"
+)
+
+// loadSmallDataset loads st_small.csv with the bank files, bankA and bankB when none is given
+func loadSmallDataset(t *testing.T, bankFiles ...string) {
+	clearRecords()
+
+	if len(bankFiles) == 0 {
+		bankFiles = []string{"../csv/bankA_20250605.csv", "../csv/bankB_20250605.csv"}
+	}
+
+	if err := CreateRecords("../csv/st_small.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := CreateBankStatementRecords(bankFiles, "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+}
+
+func TestMatched_WithPairsFromEveryStrategy(t *testing.T) {
+
+	strategies := map[string]func() (*model.Output, error){
+		"simple":     SimpleReconciliation,
+		"concurrent": ConcurrentReconcilliation,
+		"indexed":    ConcurrentReconciliationIndexed,
+	}
+
+	for name, reconcile := range strategies {
+		loadSmallDataset(t)
+
+		output, err := reconcile()
+		if err != nil {
+			t.Fatalf("%s: Expected no error during reconciliation, but got: %v", name, err)
+		}
+
+		if len(output.MatchedPairs) != output.TotalMatchedTransactions || len(output.MatchedPairs) != 4 {
+			t.Errorf("%s: Expected 4 matched pairs, got: %d", name, len(output.MatchedPairs))
+			continue
+		}
+
+		sort.Slice(output.MatchedPairs, func(i, j int) bool {
+			return output.MatchedPairs[i].System.TrxID < output.MatchedPairs[j].System.TrxID
+		})
+
+		pair := output.MatchedPairs[0]
+		if pair.System.TrxID != "TX0001" || pair.BankName != "bankA" || pair.Bank.UniqueIdentifier != "BA0001" {
+			t.Errorf("%s: Expected TX0001 to be matched to bankA BA0001, got: %s to %s %s", name, pair.System.TrxID, pair.BankName, pair.Bank.UniqueIdentifier)
+		}
+
+		if pair.Rule != model.MatchRuleExact || pair.DateDeltaDays != 0 || pair.AmountDelta != 0 {
+			t.Errorf("%s: Expected an exact match without deltas, got: %s, %d days, %.2f", name, pair.Rule, pair.DateDeltaDays, pair.AmountDelta)
+		}
+
+		if !pair.System.IsMatched || !pair.Bank.IsMatched {
+			t.Errorf("%s: Expected both records of the pair to be matched, got: %+v", name, pair)
+		}
+
+		if output.MatchedPairs[2].BankName != "bankB" {
+			t.Errorf("%s: Expected TX0003 to be matched to bankB, got: %s", name, output.MatchedPairs[2].BankName)
+		}
+	}
+
+	clearRecords()
+}
+
+func TestMatched_WithPairsWrittenToFile(t *testing.T) {
+
+	loadSmallDataset(t)
+
+	filePath := filepath.Join(t.TempDir(), "matched.jsonl")
+	matchedPairs, err := report.CreateMatchedPairsFile(filePath)
+	if err != nil {
+		t.Fatalf("Expected no error creating the matched pairs file, but got: %v", err)
+	}
+
+	MatchedPairOutput = matchedPairs
+	defer func() { MatchedPairOutput = nil }()
+
+	output, err := ConcurrentReconcilliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	if err := matchedPairs.Close(); err != nil {
+		t.Fatalf("Expected no error closing the matched pairs file, but got: %v", err)
+	}
+
+	if len(output.MatchedPairs) != 0 {
+		t.Errorf("Expected no matched pairs held in the output, got: %d", len(output.MatchedPairs))
+	}
+
+	if matchedPairs.Count() != output.TotalMatchedTransactions {
+		t.Errorf("Expected %d matched pairs written, got: %d", output.TotalMatchedTransactions, matchedPairs.Count())
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatalf("Expected matched pairs file, but got: %v", err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var pair model.MatchedPair
+		if err := json.Unmarshal(scanner.Bytes(), &pair); err != nil {
+			t.Fatalf("Expected a json matched pair per line, but got: %v", err)
+		}
+		if pair.System.TrxID == "" || pair.Bank.UniqueIdentifier == "" {
+			t.Errorf("Expected both records in the pair, got: %+v", pair)
+		}
+		lines++
+	}
+
+	if lines != 4 {
+		t.Errorf("Expected 4 lines, got: %d", lines)
+	}
+
+	// The csv export copies the pairs from the file
+	output.MatchedPairsFile = filePath
+	dir := t.TempDir()
+	if _, err := report.ExportCSV(dir, output); err != nil {
+		t.Fatalf("Expected no error exporting the results, but got: %v", err)
+	}
+
+	exported, err := os.ReadFile(filepath.Join(dir, report.MatchedPairsCSV))
+	if err != nil {
+		t.Fatalf("Expected %s, but got: %v", report.MatchedPairsCSV, err)
+	}
+	if rows := strings.Count(string(exported), "\n"); rows != 5 {
+		t.Errorf("Expected a header and 4 matched pairs in %s, got: %d rows", report.MatchedPairsCSV, rows)
+	}
+
+	clearRecords()
+}
+
+func TestMatched_WithDuplicateBankRows(t *testing.T) {
+
+	clearRecords()
+
+	dir := t.TempDir()
+	systemFile := filepath.Join(dir, "st_duplicate.csv")
+	bankFile := filepath.Join(dir, "bankA_20250605.csv")
+
+	os.WriteFile(systemFile, []byte("trxID,amount,type,transactionTime\n"+
+		"TX0001,100.00,CREDIT,2025-06-05T08:00:00Z\n"), 0o644)
+	os.WriteFile(bankFile, []byte("unique_identifier,amount,date\n"+
+		"BA0001,100.00,2025-06-05\n"+
+		"BA0002,100.00,2025-06-05\n"), 0o644)
+
+	if err := CreateRecords(systemFile, "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := CreateBankStatementRecords([]string{bankFile}, "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	if len(output.MatchedPairs) != 1 || output.TotalMatchedTransactions != 1 {
+		t.Errorf("Expected TX0001 to be matched once, got: %d pairs and %d matched", len(output.MatchedPairs), output.TotalMatchedTransactions)
+	}
+
+	if output.TotalUnmatchedBankStmts != 1 || len(output.UnmatchedBankStmts["bankA"]) != 1 {
+		t.Errorf("Expected the second bank row to be unmatched, got: %d", output.TotalUnmatchedBankStmts)
+	}
+
+	if breakdown := output.ByBank["bankA"]; breakdown.Matched != 1 || breakdown.BankCredits != 200 {
+		t.Errorf("Expected 1 match and both bank rows counted in the bankA breakdown, got: %+v", breakdown)
+	}
+
+	clearRecords()
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+)
+
+func TestOutcome_WithUnmatchedItems(t *testing.T) {
+
+	loadSmallDataset(t)
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	SetOutcome(output, model.DiscrepancyThresholds{})
+
+	if output.Outcome != model.OutcomeUnmatched || len(output.DiscrepancyBreaches) != 0 {
+		t.Errorf("Expected unmatched items without breaches, got: %s %+v", output.Outcome, output.DiscrepancyBreaches)
+	}
+
+	maxAmount, maxUnmatched, maxUnmatchedPct := 20000000.0, 3, 25.0
+	SetOutcome(output, model.DiscrepancyThresholds{MaxAmount: &maxAmount, MaxUnmatched: &maxUnmatched, MaxUnmatchedPct: &maxUnmatchedPct})
+
+	if output.Outcome != model.OutcomeDiscrepancy || len(output.DiscrepancyBreaches) != 2 {
+		t.Fatalf("Expected 2 discrepancy breaches, got: %s %+v", output.Outcome, output.DiscrepancyBreaches)
+	}
+
+	if output.DiscrepancyBreaches[0].Threshold != ThresholdMaxUnmatched || output.DiscrepancyBreaches[0].Actual != 4 {
+		t.Errorf("Expected 4 unmatched items to break max_unmatched, got: %+v", output.DiscrepancyBreaches[0])
+	}
+
+	// 4 unmatched of 8 processed records
+	if output.DiscrepancyBreaches[1].Threshold != ThresholdMaxUnmatchedPct || output.DiscrepancyBreaches[1].Actual != 50 {
+		t.Errorf("Expected 50%% unmatched items to break max_unmatched_pct, got: %+v", output.DiscrepancyBreaches[1])
+	}
+
+	clearRecords()
+}
+
+func TestOutcome_WithFullyReconciledRun(t *testing.T) {
+
+	output := &model.Output{TotalProcessedRecords: 4, TotalMatchedTransactions: 4}
+
+	maxAmount := 0.0
+	SetOutcome(output, model.DiscrepancyThresholds{MaxAmount: &maxAmount})
+
+	if output.Outcome != model.OutcomeReconciled || len(output.DiscrepancyBreaches) != 0 {
+		t.Errorf("Expected a reconciled run, got: %s %+v", output.Outcome, output.DiscrepancyBreaches)
+	}
+}
+
+func TestOutcome_WithThresholdSettings(t *testing.T) {
+
+	settings := map[string]string{"DISCREPANCY_MAX_AMOUNT": "1000.50", "DISCREPANCY_MAX_UNMATCHED": "10"}
+	thresholds, err := ParseDiscrepancyThresholds(func(name string) string { return settings[name] })
+	if err != nil {
+		t.Fatalf("Expected valid thresholds, but got: %v", err)
+	}
+
+	if *thresholds.MaxAmount != 1000.50 || *thresholds.MaxUnmatched != 10 || thresholds.MaxUnmatchedPct != nil {
+		t.Errorf("Expected max amount 1000.50, max unmatched 10 and no percentage, got: %+v", thresholds)
+	}
+
+	settings["DISCREPANCY_MAX_UNMATCHED_PCT"] = "-1"
+	if _, err := ParseDiscrepancyThresholds(func(name string) string { return settings[name] }); err == nil {
+		t.Errorf("Expected an error for a negative percentage")
+	}
+}
+
+func TestOutcome_WithCrashedWorker(t *testing.T) {
+
+	strategies := map[string]func() (*model.Output, error){
+		"concurrent": ConcurrentReconcilliation,
+		"indexed":    ConcurrentReconciliationIndexed,
+	}
+
+	for name, reconcile := range strategies {
+		loadSmallDataset(t)
+
+		// A nil transaction makes the worker processing it panic
+		model.SystemTransactionRecords = append(model.SystemTransactionRecords, nil)
+
+		output, err := reconcile()
+		if err == nil || output != nil {
+			t.Errorf("%s: Expected the panic of a worker to be returned as an error, got: %v", name, err)
+		}
+	}
+
+	clearRecords()
+}
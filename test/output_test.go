@@ -2,9 +2,12 @@ package test
 
 import (
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
 )
 
 func TestOutput_WithSmallDatasetUsingSimpleReconciliation(t *testing.T) {
@@ -331,3 +334,49 @@ func BenchmarkOutput_WithLargeDatasetUsingConcurrentReconciliationIndexed(b *tes
 		b.StopTimer() // Exclude clearRecords time
 	}
 }
+
+func TestOutput_WithBankRowOfAnotherDay(t *testing.T) {
+
+	strategies := map[string]func() (*model.Output, error){
+		"simple":     SimpleReconciliation,
+		"concurrent": ConcurrentReconcilliation,
+		"indexed":    ConcurrentReconciliationIndexed,
+	}
+
+	dir := t.TempDir()
+	systemFile := filepath.Join(dir, "st_days.csv")
+	bankFile := filepath.Join(dir, "bankA_20250606.csv")
+
+	// Same amount and direction, but the bank row is a day later: it is unmatched, not invalid
+	os.WriteFile(systemFile, []byte("trxID,amount,type,transactionTime\n"+
+		"TX0001,100.00,CREDIT,2025-06-05T08:00:00Z\n"), 0o644)
+	os.WriteFile(bankFile, []byte("unique_identifier,amount,date\n"+
+		"BA0001,100.00,2025-06-06\n"), 0o644)
+
+	for name, reconcile := range strategies {
+		clearRecords()
+
+		if err := CreateRecords(systemFile, "systemTransaction", "20250601", "20250630"); err != nil {
+			t.Errorf("%s: Expected no error for valid record, but got: %v", name, err)
+		}
+
+		if err := CreateBankStatementRecords([]string{bankFile}, "20250601", "20250630"); err != nil {
+			t.Errorf("%s: Expected no error for valid record, but got: %v", name, err)
+		}
+
+		output, err := reconcile()
+		if err != nil {
+			t.Fatalf("%s: Expected no error during reconciliation, but got: %v", name, err)
+		}
+
+		if output.TotalInvalidRecords != 0 {
+			t.Errorf("%s: Expected TotalInvalidRecords to be 0, got: %d", name, output.TotalInvalidRecords)
+		}
+
+		if output.TotalMatchedTransactions != 0 || output.TotalUnmatchedTransactions != 2 {
+			t.Errorf("%s: Expected both records to be unmatched, got: %d matched and %d unmatched", name, output.TotalMatchedTransactions, output.TotalUnmatchedTransactions)
+		}
+	}
+
+	clearRecords()
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+)
+
+func TestQuality_WithFileStats(t *testing.T) {
+
+	clearRecords()
+
+	if err := CreateRecords("../csv/bankR_20250605_rejected.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for rejected records, but got: %v", err)
+	}
+
+	if len(model.LoadedFiles) != 1 {
+		t.Fatalf("Expected 1 loaded file, got: %d", len(model.LoadedFiles))
+	}
+
+	stats := model.LoadedFiles[0]
+	if stats.TotalRows != 5 || stats.AcceptedRows != 1 || stats.RejectedRows != 3 || stats.OutOfRangeRows != 1 {
+		t.Errorf("Expected 5 rows, 1 accepted, 3 rejected and 1 out of range, got: %+v", stats)
+	}
+
+	if stats.BankName != "bankR" || stats.RecordType != "bankStatement" {
+		t.Errorf("Expected bankR bank statement file, got: %s %s", stats.BankName, stats.RecordType)
+	}
+
+	clearRecords()
+}
+
+func TestQuality_WithThresholdsCrossed(t *testing.T) {
+
+	clearRecords()
+
+	if err := CreateRecords("../csv/st_small.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := CreateRecords("../csv/bankR_20250605_rejected.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for rejected records, but got: %v", err)
+	}
+
+	maxRejected, maxOutOfRange, minRows := 50.0, 10.0, 20
+	breaches := CheckDataQuality(model.DataQualityThresholds{
+		PerFile: model.QualityThresholds{MaxRejectedPct: &maxRejected, MaxOutOfRangePct: &maxOutOfRange},
+		Global:  model.QualityThresholds{MinRowCount: &minRows},
+	})
+
+	if len(breaches) != 3 {
+		t.Fatalf("Expected 3 breaches, got: %+v", breaches)
+	}
+
+	if breaches[0].File != "../csv/bankR_20250605_rejected.csv" || breaches[0].Threshold != ThresholdMaxRejectedPct || breaches[0].Actual != 60 {
+		t.Errorf("Expected bankR to break max_rejected_pct with 60%%, got: %+v", breaches[0])
+	}
+
+	if breaches[1].Threshold != ThresholdMaxOutOfRangePct || breaches[1].Actual != 20 {
+		t.Errorf("Expected bankR to break max_out_of_range_pct with 20%%, got: %+v", breaches[1])
+	}
+
+	if breaches[2].File != "" || breaches[2].Threshold != ThresholdMinRowCount || breaches[2].Actual != 11 {
+		t.Errorf("Expected all files to break min_row_count with 11 rows, got: %+v", breaches[2])
+	}
+
+	clearRecords()
+}
+
+func TestQuality_WithThresholdsFromEnvironment(t *testing.T) {
+	env := map[string]string{"DQ_MAX_REJECTED_PCT": "5", "DQ_GLOBAL_MIN_ROWS": "100"}
+
+	thresholds, err := ParseQualityThresholds(func(name string) string { return env[name] })
+	if err != nil {
+		t.Fatalf("Expected no error for valid thresholds, but got: %v", err)
+	}
+
+	if thresholds.PerFile.MaxRejectedPct == nil || *thresholds.PerFile.MaxRejectedPct != 5 {
+		t.Errorf("Expected per file max rejected percentage of 5, got: %v", thresholds.PerFile.MaxRejectedPct)
+	}
+
+	if thresholds.Global.MinRowCount == nil || *thresholds.Global.MinRowCount != 100 {
+		t.Errorf("Expected global minimum of 100 rows, got: %v", thresholds.Global.MinRowCount)
+	}
+
+	if thresholds.PerFile.MinRowCount != nil || thresholds.Global.MaxOutOfRangePct != nil {
+		t.Errorf("Expected unset thresholds to be disabled, got: %+v", thresholds)
+	}
+
+	env["DQ_MAX_OUT_OF_RANGE_PCT"] = "ten"
+	if _, err := ParseQualityThresholds(func(name string) string { return env[name] }); err == nil {
+		t.Errorf("Expected an error for invalid threshold, but got nil")
+	}
+}
+
+func TestQuality_WithFileProfile(t *testing.T) {
+
+	clearRecords()
+
+	largest := ProfileLargestAmounts
+	ProfileLargestAmounts = 2
+	defer func() {
+		ProfileLargestAmounts = largest
+	}()
+
+	if err := CreateRecords("../csv/st_small.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := CreateRecords("../csv/bankR_20250605_rejected.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for rejected records, but got: %v", err)
+	}
+
+	if len(model.LoadedFiles) != 2 {
+		t.Fatalf("Expected 2 file profiles, got: %d", len(model.LoadedFiles))
+	}
+
+	profile := model.LoadedFiles[0]
+	if profile.MinDate != "2025-06-05" || profile.MaxDate != "2025-06-05" || profile.CountPerDay["2025-06-05"] != 6 {
+		t.Errorf("Expected 6 rows on 2025-06-05, got: %s to %s with %v", profile.MinDate, profile.MaxDate, profile.CountPerDay)
+	}
+
+	if math.Abs(profile.SumCredits-18676234.08) > 0.001 || math.Abs(profile.SumDebits-15939223.46) > 0.001 {
+		t.Errorf("Expected credits 18676234.08 and debits 15939223.46, got: %.2f and %.2f", profile.SumCredits, profile.SumDebits)
+	}
+
+	if len(profile.LargestAmounts) != 2 || profile.LargestAmounts[0].Identifier != "TX0003" || profile.LargestAmounts[1].Identifier != "TX0001" {
+		t.Errorf("Expected TX0003 and TX0001 as largest amounts, got: %+v", profile.LargestAmounts)
+	}
+
+	profile = model.LoadedFiles[1]
+	expectedReasons := map[string]int{model.ReasonBadAmount: 1, model.ReasonBadDate: 1, model.ReasonOutOfRange: 1, model.ReasonWrongColumnCount: 1}
+	for reason, count := range expectedReasons {
+		if profile.RejectedByReason[reason] != count {
+			t.Errorf("Expected %d rows rejected for %s, got: %d", count, reason, profile.RejectedByReason[reason])
+		}
+	}
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	if len(output.FileProfiles) != 2 {
+		t.Errorf("Expected 2 file profiles in output, got: %d", len(output.FileProfiles))
+	}
+
+	clearRecords()
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"reflect"
 	"testing"
 
 	. "github.com/sientong/reconciliation-service/imp"
@@ -105,10 +106,233 @@ func TestRecord_WithIncorrectDataType(t *testing.T) {
 		t.Errorf("Expected no system transaction records, got: %d", len(model.SystemTransactionRecords))
 	}
 
+	if len(model.RejectedRecords) != 1 {
+		t.Fatalf("Expected 1 rejected record, got: %d", len(model.RejectedRecords))
+	}
+
+	rejected := model.RejectedRecords[0]
+	if rejected.SourceFile != "../csv/st_incorrect_record.csv" || rejected.Line != 2 {
+		t.Errorf("Expected rejected record at ../csv/st_incorrect_record.csv:2, got: %s:%d", rejected.SourceFile, rejected.Line)
+	}
+
+	if rejected.Field != "amount" || rejected.Reason != model.ReasonBadAmount {
+		t.Errorf("Expected rejected record for field amount with reason bad_amount, got: %s %s", rejected.Field, rejected.Reason)
+	}
+
+	if rejected.Raw != "TX0001,AS,DEBIT,2025-06-05T08:01:00Z" {
+		t.Errorf("Expected raw content 'TX0001,AS,DEBIT,2025-06-05T08:01:00Z', got: %s", rejected.Raw)
+	}
+
+	clearRecords()
+}
+
+func TestRecord_WithDebitCreditColumns(t *testing.T) {
+
+	clearRecords()
+
+	err := CreateRecords("../csv/bankC_20250605.csv", "bankStatement", "20250601", "20250630")
+	if err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	records := model.BankStatementRecordsMap["bankC"]
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 bank statement records, got: %d", len(records))
+	}
+
+	if records[0].Amount != -6241250.16 || records[0].Direction != model.DirectionDebit {
+		t.Errorf("Expected first record to be a debit of -6241250.16, got: %.2f %s", records[0].Amount, records[0].Direction)
+	}
+
+	if records[1].Amount != 3935387.85 || records[1].Direction != model.DirectionCredit {
+		t.Errorf("Expected second record to be a credit of 3935387.85, got: %.2f %s", records[1].Amount, records[1].Direction)
+	}
+
+	if records[2].Amount != 9697973.3 || records[2].Direction != model.DirectionCredit {
+		t.Errorf("Expected third record to be a credit of 9697973.3, got: %.2f %s", records[2].Amount, records[2].Direction)
+	}
+
+	clearRecords()
+}
+
+func TestRecord_WithDebitCreditIndicator(t *testing.T) {
+
+	clearRecords()
+
+	err := CreateRecords("../csv/bankD_20250605.csv", "bankStatement", "20250601", "20250630")
+	if err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	records := model.BankStatementRecordsMap["bankD"]
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 bank statement records, got: %d", len(records))
+	}
+
+	if records[0].Amount != -6241250.16 || records[0].Direction != model.DirectionDebit {
+		t.Errorf("Expected first record to be a debit of -6241250.16, got: %.2f %s", records[0].Amount, records[0].Direction)
+	}
+
+	if records[1].Amount != 3935387.85 || records[1].Direction != model.DirectionCredit {
+		t.Errorf("Expected second record to be a credit of 3935387.85, got: %.2f %s", records[1].Amount, records[1].Direction)
+	}
+
+	if records[2].Amount != -9697973.3 || records[2].Direction != model.DirectionDebit {
+		t.Errorf("Expected third record to be a debit of -9697973.3, got: %.2f %s", records[2].Amount, records[2].Direction)
+	}
+
+	clearRecords()
+}
+
+func TestRecord_WithRejectedRecordReasons(t *testing.T) {
+
+	clearRecords()
+
+	err := CreateRecords("../csv/bankR_20250605_rejected.csv", "bankStatement", "20250601", "20250630")
+	if err != nil {
+		t.Errorf("Expected no error for rejected records, but got: %v", err)
+	}
+
+	if len(model.BankStatementRecordsMap["bankR"]) != 1 {
+		t.Errorf("Expected 1 bank statement record, got: %d", len(model.BankStatementRecordsMap["bankR"]))
+	}
+
+	expected := []struct {
+		line   int
+		field  string
+		reason string
+	}{
+		{2, "amount", model.ReasonBadAmount},
+		{3, "date", model.ReasonBadDate},
+		{4, "date", model.ReasonOutOfRange},
+		{5, "", model.ReasonWrongColumnCount},
+	}
+
+	if len(model.RejectedRecords) != len(expected) {
+		t.Fatalf("Expected %d rejected records, got: %d", len(expected), len(model.RejectedRecords))
+	}
+
+	for i, rejected := range model.RejectedRecords {
+		if rejected.Line != expected[i].line || rejected.Field != expected[i].field || rejected.Reason != expected[i].reason {
+			t.Errorf("Expected rejected record at line %d for field '%s' with reason %s, got: line %d field '%s' reason %s",
+				expected[i].line, expected[i].field, expected[i].reason, rejected.Line, rejected.Field, rejected.Reason)
+		}
+	}
+
+	clearRecords()
+}
+
+func TestRecord_WithStreamingLoaderKeepsFileOrder(t *testing.T) {
+
+	clearRecords()
+
+	workers, batchSize := LoaderWorkers, LoaderBatchSize
+	defer func() {
+		LoaderWorkers, LoaderBatchSize = workers, batchSize
+	}()
+
+	// Sequential reference load
+	LoaderWorkers, LoaderBatchSize = 1, 1
+	if err := CreateRecords("../csv/system_transactions_large.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Fatalf("Expected no error for valid record, but got: %v", err)
+	}
+	if err := CreateBankStatementRecords([]string{"../csv/bankA_20250605_large.csv", "../csv/bankB_20250605_large.csv", "../csv/bankA_20250605.csv"}, "20250601", "20250630"); err != nil {
+		t.Fatalf("Expected no error for valid record, but got: %v", err)
+	}
+	expectedTransactions := model.SystemTransactionRecords
+	expectedBankStatements := model.BankStatementRecordsMap
+
+	clearRecords()
+
+	LoaderWorkers, LoaderBatchSize = 8, 97
+	if err := CreateRecords("../csv/system_transactions_large.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Fatalf("Expected no error for valid record, but got: %v", err)
+	}
+	if err := CreateBankStatementRecords([]string{"../csv/bankA_20250605_large.csv", "../csv/bankB_20250605_large.csv", "../csv/bankA_20250605.csv"}, "20250601", "20250630"); err != nil {
+		t.Fatalf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.SystemTransactionRecords) != len(expectedTransactions) {
+		t.Fatalf("Expected %d system transaction records, got: %d", len(expectedTransactions), len(model.SystemTransactionRecords))
+	}
+	for i, record := range model.SystemTransactionRecords {
+		if !reflect.DeepEqual(*record, *expectedTransactions[i]) {
+			t.Fatalf("Expected record %d to be %+v, got: %+v", i, *expectedTransactions[i], *record)
+		}
+	}
+
+	for bankName, records := range expectedBankStatements {
+		if len(model.BankStatementRecordsMap[bankName]) != len(records) {
+			t.Fatalf("Expected %d records for %s, got: %d", len(records), bankName, len(model.BankStatementRecordsMap[bankName]))
+		}
+		for i, record := range model.BankStatementRecordsMap[bankName] {
+			if !reflect.DeepEqual(*record, *records[i]) {
+				t.Fatalf("Expected %s record %d to be %+v, got: %+v", bankName, i, *records[i], *record)
+			}
+		}
+	}
+
+	// bankA_20250605.csv repeats 2 rows of bankA_20250605_large.csv, which are only loaded once
+	if len(model.BankStatementRecordsMap["bankA"]) != 51 {
+		t.Errorf("Expected 51 bankA records, got: %d", len(model.BankStatementRecordsMap["bankA"]))
+	}
+
+	clearRecords()
+}
+
+func BenchmarkRecords_WithLargeSystemTransactionFile(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		clearRecords()
+
+		if err := CreateRecords("../csv/system_transactions_large.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+			b.Fatalf("create system records failed: %v", err)
+		}
+	}
+
 	clearRecords()
 }
 
 func clearRecords() {
 	model.SystemTransactionRecords = nil
 	model.BankStatementRecordsMap = make(map[string][]*model.BankStatementRecord)
+	model.RejectedRecords = nil
+	model.RecordWarnings = nil
+	model.LoadedFiles = nil
+	model.BalanceChecks = nil
+	model.Run = model.RunMetadata{}
+	model.FileFingerprints = make(map[string]model.FileFingerprint)
+	model.RowFingerprints = make(map[string]map[string]struct{})
+}
+
+func TestRecord_WithExtraColumns(t *testing.T) {
+
+	clearRecords()
+
+	if err := CreateRecords("../csv/st_aliases.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := CreateRecords("../csv/bankF_20250605_extra.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.SystemTransactionRecords) != 2 || len(model.BankStatementRecordsMap["bankF"]) != 2 {
+		t.Fatalf("Expected 2 system and 2 bank records, got: %d and %d", len(model.SystemTransactionRecords), len(model.BankStatementRecordsMap["bankF"]))
+	}
+
+	trx := model.SystemTransactionRecords[1]
+	if trx.TrxID != "TX0002" || trx.Type != "credit" || trx.Extra["channel"] != "branch" {
+		t.Errorf("Expected TX0002 credit from branch, got: %+v", *trx)
+	}
+
+	stmt := model.BankStatementRecordsMap["bankF"][0]
+	if stmt.UniqueIdentifier != "BF0001" || stmt.Amount != -6241250.16 || stmt.Date != "2025-06-05" {
+		t.Errorf("Expected BF0001 -6241250.16 on 2025-06-05, got: %+v", *stmt)
+	}
+
+	if stmt.Extra["Description"] != "Transfer to supplier" || stmt.Extra["Balance"] != "3758749.84" || len(stmt.Extra) != 2 {
+		t.Errorf("Expected description and balance as extra attributes, got: %v", stmt.Extra)
+	}
+
+	clearRecords()
 }
@@ -0,0 +1,478 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/report"
+)
+
+// reportBankFiles have unmatched, rejected and balance rows, so every part of the reports is filled
+var reportBankFiles = []string{"../csv/bankA_20250605.csv", "../csv/bankR_20250605_rejected.csv", "../csv/bankE_20250605.csv"}
+
+func TestReport_WithRejectedRecordsCSV(t *testing.T) {
+
+	clearRecords()
+
+	if err := CreateRecords("../csv/st_small.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := CreateRecords("../csv/bankR_20250605_rejected.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for rejected records, but got: %v", err)
+	}
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	if output.TotalInvalidRecords != 4 {
+		t.Errorf("Expected TotalInvalidRecords to be 4, got: %d", output.TotalInvalidRecords)
+	}
+
+	if len(output.RejectedRecords) != 4 {
+		t.Errorf("Expected 4 rejected records in output, got: %d", len(output.RejectedRecords))
+	}
+
+	filePath := filepath.Join(t.TempDir(), "rejected.csv")
+	if err := report.WriteRejectedRecordsCSV(filePath, output.RejectedRecords); err != nil {
+		t.Fatalf("Expected no error writing rejected records, but got: %v", err)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatalf("Expected rejected records file, but got: %v", err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected a valid csv file, but got: %v", err)
+	}
+
+	if len(rows) != 5 {
+		t.Fatalf("Expected header and 4 rows, got: %d rows", len(rows))
+	}
+
+	expectedRow := []string{"../csv/bankR_20250605_rejected.csv", "2", "amount", "bad_amount"}
+	for i, value := range expectedRow {
+		if rows[1][i] != value {
+			t.Errorf("Expected column %s to be '%s', got: '%s'", rows[0][i], value, rows[1][i])
+		}
+	}
+
+	if rows[1][5] != "BR0001,abc,2025-06-05" {
+		t.Errorf("Expected raw content 'BR0001,abc,2025-06-05', got: %s", rows[1][5])
+	}
+
+	clearRecords()
+}
+
+func TestReport_WithJSONOutput(t *testing.T) {
+
+	clearRecords()
+
+	if err := CreateRecords("../csv/st_small.csv", "systemTransaction", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if err := CreateRecords("../csv/bankR_20250605_rejected.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for rejected records, but got: %v", err)
+	}
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	if err := report.WriteJSON(&buffer, output); err != nil {
+		t.Fatalf("Expected no error writing json, but got: %v", err)
+	}
+
+	var document struct {
+		SchemaVersion               string                                 `json:"schema_version"`
+		TotalInvalidRecords         int                                    `json:"total_invalid_records"`
+		UnmatchedSystemTransactions []model.InternalTransactionRecord      `json:"unmatched_system_transactions"`
+		UnmatchedBankStmts          map[string][]model.BankStatementRecord `json:"unmatched_bank_stmts"`
+		RejectedRecords             []model.RejectedRecord                 `json:"rejected_records"`
+		Metadata                    model.RunMetadata                      `json:"metadata"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &document); err != nil {
+		t.Fatalf("Expected valid json, but got: %v", err)
+	}
+
+	if document.SchemaVersion != report.SchemaVersion {
+		t.Errorf("Expected schema version %s, got: %s", report.SchemaVersion, document.SchemaVersion)
+	}
+
+	if document.TotalInvalidRecords != 4 || len(document.RejectedRecords) != 4 {
+		t.Errorf("Expected 4 invalid and 4 rejected records, got: %d and %d", document.TotalInvalidRecords, len(document.RejectedRecords))
+	}
+
+	if len(document.UnmatchedSystemTransactions) != output.TotalUnmatchedSystemTransactions {
+		t.Errorf("Expected %d unmatched system transactions, got: %d", output.TotalUnmatchedSystemTransactions, len(document.UnmatchedSystemTransactions))
+	}
+
+	if len(document.UnmatchedBankStmts["bankR"]) != output.TotalUnmatchedBankStmts {
+		t.Errorf("Expected %d unmatched bankR statements, got: %d", output.TotalUnmatchedBankStmts, len(document.UnmatchedBankStmts["bankR"]))
+	}
+
+	if len(document.Metadata.Ingestion) != 2 || document.Metadata.Ingestion[1].BankName != "bankR" {
+		t.Errorf("Expected the ingestion of 2 files in the metadata, got: %+v", document.Metadata.Ingestion)
+	}
+
+	clearRecords()
+}
+
+func TestReport_WithJSONOutputOfEmptyLists(t *testing.T) {
+
+	var buffer bytes.Buffer
+	if err := report.WriteJSON(&buffer, &model.Output{}); err != nil {
+		t.Fatalf("Expected no error writing json, but got: %v", err)
+	}
+
+	for _, field := range []string{`"unmatched_system_transactions": []`, `"unmatched_bank_stmts": {}`, `"rejected_records": []`} {
+		if !strings.Contains(buffer.String(), field) {
+			t.Errorf("Expected %s in the json output, got: %s", field, buffer.String())
+		}
+	}
+}
+
+func readCSV(t *testing.T, filePath string) [][]string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatalf("Expected %s, but got: %v", filePath, err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid csv in %s, but got: %v", filePath, err)
+	}
+	return rows
+}
+
+func TestReport_WithTextBanksInOrder(t *testing.T) {
+
+	output := &model.Output{UnmatchedBankStmts: map[string][]model.BankStatementRecord{
+		"bankC": {{UniqueIdentifier: "BC0001", Amount: 300, Date: "2025-06-05"}},
+		"bankA": {{UniqueIdentifier: "BA0001", Amount: 100, Date: "2025-06-05"}},
+		"bankB": {{UniqueIdentifier: "BB0001", Amount: 200, Date: "2025-06-05"}},
+	}}
+
+	for range 10 {
+		var buf bytes.Buffer
+		report.WriteText(&buf, output)
+		text := buf.String()
+
+		a, b, c := strings.Index(text, " + bankA"), strings.Index(text, " + bankB"), strings.Index(text, " + bankC")
+		if a < 0 || a > b || b > c {
+			t.Fatalf("Expected the unmatched bank statements by bank name, got:\n%s", text)
+		}
+	}
+}
+
+func TestReport_WithTextMatchedPairsCount(t *testing.T) {
+
+	loadSmallDataset(t)
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	var buf bytes.Buffer
+	report.WriteText(&buf, output)
+	text := buf.String()
+
+	if !strings.Contains(text, fmt.Sprintf("Matched pairs: %d\n", len(output.MatchedPairs))) {
+		t.Errorf("Expected the count of matched pairs, got:\n%s", text)
+	}
+
+	for _, pair := range output.MatchedPairs {
+		if strings.Contains(text, pair.System.TrxID) {
+			t.Errorf("Expected the matched pairs not to be listed, got %s in:\n%s", pair.System.TrxID, text)
+		}
+	}
+
+	clearRecords()
+}
+
+func TestReport_WithCSVExport(t *testing.T) {
+
+	loadSmallDataset(t, "../csv/bankA_20250605.csv", "../csv/bankB_20250605.csv", "../csv/bankR_20250605_rejected.csv")
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "export")
+	written, err := report.ExportCSV(dir, output)
+	if err != nil {
+		t.Fatalf("Expected no error exporting csv, but got: %v", err)
+	}
+
+	if len(written) != 4 {
+		t.Errorf("Expected 4 files written, got: %v", written)
+	}
+
+	rows := readCSV(t, filepath.Join(dir, report.UnmatchedSystemTransactionsCSV))
+	if strings.Join(rows[0], ",") != "trx_id,amount,type,transaction_time" || len(rows)-1 != output.TotalUnmatchedSystemTransactions {
+		t.Errorf("Expected header and %d unmatched system transactions, got: %v", output.TotalUnmatchedSystemTransactions, rows)
+	}
+
+	rows = readCSV(t, filepath.Join(dir, report.UnmatchedBankStatementsCSV))
+	if strings.Join(rows[0], ",") != "bank,unique_identifier,amount,direction,date" || len(rows)-1 != output.TotalUnmatchedBankStmts {
+		t.Errorf("Expected header and %d unmatched bank statements, got: %v", output.TotalUnmatchedBankStmts, rows)
+	}
+
+	// Banks are sorted by name
+	if rows[1][0] != "bankA" || rows[len(rows)-1][0] != "bankR" {
+		t.Errorf("Expected unmatched bank statements from bankA to bankR, got: %s to %s", rows[1][0], rows[len(rows)-1][0])
+	}
+
+	rows = readCSV(t, filepath.Join(dir, report.MatchedPairsCSV))
+	if len(rows)-1 != output.TotalMatchedTransactions || len(rows[0]) != 12 {
+		t.Errorf("Expected %d matched pairs with 12 columns, got: %v", output.TotalMatchedTransactions, rows)
+	}
+
+	for _, row := range rows[1:] {
+		if row[1] != report.FormatAmount(mustParseFloat(t, row[1])) || row[6] != report.FormatAmount(mustParseFloat(t, row[6])) {
+			t.Errorf("Expected amounts with two decimals, got: %s and %s", row[1], row[6])
+		}
+	}
+
+	rows = readCSV(t, filepath.Join(dir, report.RejectedRecordsCSV))
+	if len(rows)-1 != len(output.RejectedRecords) {
+		t.Errorf("Expected %d rejected rows, got: %d", len(output.RejectedRecords), len(rows)-1)
+	}
+
+	clearRecords()
+}
+
+func mustParseFloat(t *testing.T, value string) float64 {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		t.Fatalf("Expected a number, got: %s", value)
+	}
+	return number
+}
+
+func TestReport_WithHTMLReport(t *testing.T) {
+
+	loadSmallDataset(t, reportBankFiles...)
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "report.html")
+	if err := report.WriteHTML(filePath, output); err != nil {
+		t.Fatalf("Expected no error writing the html report, but got: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Expected html report, but got: %v", err)
+	}
+	html := string(content)
+
+	// Self-contained: no external stylesheets, scripts or images
+	for _, external := range []string{"http://", "https://", "<link", " src="} {
+		if strings.Contains(html, external) {
+			t.Errorf("Expected no external assets, found: %s", external)
+		}
+	}
+
+	for _, expected := range []string{
+		"<style>", "<script>", `class="sortable"`,
+		"<td>bankA</td>", "<td>bankE</td>", `class="status-balanced">balanced`,
+		output.UnmatchedSystemTransactions[0].TrxID,
+		output.Metadata.StartedAt,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected %s in the html report", expected)
+		}
+	}
+
+	// A header row per table, a row per bank, unmatched item, rejected row and input file
+	rows := strings.Count(html, "<tr>")
+	expectedRows := 5 + 3 + output.TotalUnmatchedSystemTransactions + output.TotalUnmatchedBankStmts + len(output.RejectedRecords) + len(output.Metadata.Ingestion)
+	if rows != expectedRows {
+		t.Errorf("Expected %d table rows, got: %d", expectedRows, rows)
+	}
+
+	clearRecords()
+}
+
+// readXLSXPart returns the content of a part of a workbook
+func readXLSXPart(t *testing.T, workbook *zip.ReadCloser, name string) string {
+	for _, file := range workbook.File {
+		if file.Name != name {
+			continue
+		}
+		part, err := file.Open()
+		if err != nil {
+			t.Fatalf("Expected no error opening %s, but got: %v", name, err)
+		}
+		defer part.Close()
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("Expected no error reading %s, but got: %v", name, err)
+		}
+		return string(content)
+	}
+	t.Fatalf("Expected part %s in the workbook", name)
+	return ""
+}
+
+func TestReport_WithXLSXWorkbook(t *testing.T) {
+
+	loadSmallDataset(t, reportBankFiles...)
+
+	output, err := SimpleReconciliation()
+	if err != nil {
+		t.Fatalf("Expected no error during reconciliation, but got: %v", err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "report.xlsx")
+	if err := report.WriteXLSX(filePath, output); err != nil {
+		t.Fatalf("Expected no error writing the workbook, but got: %v", err)
+	}
+
+	workbook, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatalf("Expected a zip archive, but got: %v", err)
+	}
+	defer workbook.Close()
+
+	sheets := regexp.MustCompile(`<sheet name="([^"]+)"`).FindAllStringSubmatch(readXLSXPart(t, workbook, "xl/workbook.xml"), -1)
+	var names []string
+	for _, sheet := range sheets {
+		names = append(names, sheet[1])
+	}
+
+	var expectedNames []string
+	expectedNames = append(expectedNames, "Summary", "Matched", "Unmatched System")
+	for _, bankName := range []string{"bankA", "bankE", "bankR"} {
+		if _, exists := output.UnmatchedBankStmts[bankName]; exists {
+			expectedNames = append(expectedNames, "Unmatched "+bankName)
+		}
+	}
+	expectedNames = append(expectedNames, "Rejected")
+
+	if strings.Join(names, ",") != strings.Join(expectedNames, ",") {
+		t.Fatalf("Expected sheets %v, got: %v", expectedNames, names)
+	}
+
+	rows := regexp.MustCompile(`<row `)
+
+	// Amounts are numbers with the amount format, dates are date serials
+	unmatchedSystem := readXLSXPart(t, workbook, "xl/worksheets/sheet3.xml")
+	if count := len(rows.FindAllString(unmatchedSystem, -1)); count != output.TotalUnmatchedSystemTransactions+1 {
+		t.Errorf("Expected %d rows in Unmatched System, got: %d", output.TotalUnmatchedSystemTransactions+1, count)
+	}
+
+	trx := output.UnmatchedSystemTransactions[0]
+	if !strings.Contains(unmatchedSystem, `<c r="B2" s="1"><v>`+strconv.FormatFloat(trx.Amount, 'f', -1, 64)+`</v></c>`) {
+		t.Errorf("Expected the amount of %s as a number cell, got: %s", trx.TrxID, unmatchedSystem)
+	}
+
+	transactionTime, _ := time.Parse(time.RFC3339, trx.TransactionTime)
+	serial := strconv.FormatFloat(transactionTime.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours()/24, 'f', -1, 64)
+	if !strings.Contains(unmatchedSystem, `<c r="D2" s="3"><v>`+serial+`</v></c>`) {
+		t.Errorf("Expected the transaction time of %s as a date cell %s, got: %s", trx.TrxID, serial, unmatchedSystem)
+	}
+
+	matched := readXLSXPart(t, workbook, "xl/worksheets/sheet2.xml")
+	if count := len(rows.FindAllString(matched, -1)); count != len(output.MatchedPairs)+1 {
+		t.Errorf("Expected %d rows in Matched, got: %d", len(output.MatchedPairs)+1, count)
+	}
+
+	rejected := readXLSXPart(t, workbook, fmt.Sprintf("xl/worksheets/sheet%d.xml", len(names)))
+	if count := len(rows.FindAllString(rejected, -1)); count != len(output.RejectedRecords)+1 {
+		t.Errorf("Expected %d rows in Rejected, got: %d", len(output.RejectedRecords)+1, count)
+	}
+
+	clearRecords()
+}
+
+func TestReport_WithXLSXSheetNamesOfAnyCase(t *testing.T) {
+
+	output := &model.Output{UnmatchedBankStmts: map[string][]model.BankStatementRecord{
+		"system": {{UniqueIdentifier: "S0001", Amount: 100, Date: "2025-06-05"}},
+		"SYSTEM": {{UniqueIdentifier: "S0002", Amount: 200, Date: "2025-06-05"}},
+	}}
+
+	filePath := filepath.Join(t.TempDir(), "report.xlsx")
+	if err := report.WriteXLSX(filePath, output); err != nil {
+		t.Fatalf("Expected no error writing the workbook, but got: %v", err)
+	}
+
+	workbook, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatalf("Expected a zip archive, but got: %v", err)
+	}
+	defer workbook.Close()
+
+	sheets := regexp.MustCompile(`<sheet name="([^"]+)"`).FindAllStringSubmatch(readXLSXPart(t, workbook, "xl/workbook.xml"), -1)
+	seen := make(map[string]string)
+	for _, sheet := range sheets {
+		if other, exists := seen[strings.ToLower(sheet[1])]; exists {
+			t.Errorf("Expected sheet names which differ regardless of case, got: %s and %s", other, sheet[1])
+		}
+		seen[strings.ToLower(sheet[1])] = sheet[1]
+	}
+
+	if len(sheets) != 6 {
+		t.Errorf("Expected 6 sheets, got: %d", len(sheets))
+	}
+}
+
+func TestReport_WithLargeXLSXWorkbook(t *testing.T) {
+
+	output := &model.Output{UnmatchedBankStmts: map[string][]model.BankStatementRecord{}}
+	for i := 0; i < 100000; i++ {
+		output.UnmatchedSystemTransactions = append(output.UnmatchedSystemTransactions, model.InternalTransactionRecord{
+			TrxID: fmt.Sprintf("TX%06d", i), Amount: float64(i) + 0.25, Type: "CREDIT", TransactionTime: "2025-06-05T08:01:00Z",
+		})
+	}
+	output.TotalUnmatchedSystemTransactions = len(output.UnmatchedSystemTransactions)
+
+	filePath := filepath.Join(t.TempDir(), "large.xlsx")
+	if err := report.WriteXLSX(filePath, output); err != nil {
+		t.Fatalf("Expected no error writing the workbook, but got: %v", err)
+	}
+
+	workbook, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatalf("Expected a zip archive, but got: %v", err)
+	}
+	defer workbook.Close()
+
+	unmatchedSystem := readXLSXPart(t, workbook, "xl/worksheets/sheet3.xml")
+	if count := strings.Count(unmatchedSystem, "<row "); count != 100001 {
+		t.Errorf("Expected 100001 rows, got: %d", count)
+	}
+
+	if !strings.Contains(unmatchedSystem, `<c r="A100001" t="inlineStr"><is><t xml:space="preserve">TX099999</t></is></c>`) {
+		t.Errorf("Expected the last transaction on row 100001")
+	}
+}
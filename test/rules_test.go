@@ -0,0 +1,126 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/util"
+	"github.com/sientong/reconciliation-service/validator"
+)
+
+func TestRules_WithDefaultSystemTransactionRules(t *testing.T) {
+	fields := map[string]string{"trxID": "TX0001", "amount": "100.50", "type": "DEBIT", "transactionTime": "2025-06-05T08:01:00Z"}
+
+	violations, err := validator.ValidateRecord(fields, "systemTransaction", util.DefaultAmountFormat)
+	if err != nil {
+		t.Fatalf("Expected no error for known record type, but got: %v", err)
+	}
+
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations for valid record, got: %+v", violations)
+	}
+
+	fields["type"] = "transfer"
+	fields["transactionTime"] = "20250605"
+
+	violations, _ = validator.ValidateRecord(fields, "systemTransaction", util.DefaultAmountFormat)
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got: %+v", violations)
+	}
+
+	if violations[0].Reason != model.ReasonBadType || violations[1].Reason != model.ReasonBadDate {
+		t.Errorf("Expected bad_type and bad_date violations, got: %s and %s", violations[0].Reason, violations[1].Reason)
+	}
+
+	if rejection := validator.FirstRejection(violations); rejection == nil || rejection.Field != "type" {
+		t.Errorf("Expected the type violation to reject the record, got: %+v", rejection)
+	}
+}
+
+func TestRules_WithMissingRequiredField(t *testing.T) {
+	fields := map[string]string{"unique_identifier": "", "amount": "100", "date": "2025-06-05"}
+
+	violations, _ := validator.ValidateRecord(fields, "bankStatement", util.DefaultAmountFormat)
+	if len(violations) != 1 || violations[0].Reason != model.ReasonMissingField {
+		t.Errorf("Expected a missing_field violation, got: %+v", violations)
+	}
+}
+
+func TestRules_WithConfiguredWarnRule(t *testing.T) {
+
+	clearRecords()
+
+	defaultRules := validator.RuleSets["bankStatement"]
+	defer func() {
+		validator.RuleSets["bankStatement"] = defaultRules
+	}()
+
+	err := validator.ConfigureRules(map[string][]validator.RuleConfig{
+		"bankStatement": {
+			{Field: "amount", Kind: validator.RuleAmount},
+			{Field: "amount", Kind: validator.RuleAmountRange, Max: floatPtr(5000000), Severity: validator.SeverityWarn},
+			{Field: "unique_identifier", Kind: validator.RuleRegex, Pattern: "^BA[0-9]{4}$"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error configuring rules, but got: %v", err)
+	}
+
+	if err := CreateRecords("../csv/bankA_20250605.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.BankStatementRecordsMap["bankA"]) != 3 {
+		t.Errorf("Expected 3 bank statement records, got: %d", len(model.BankStatementRecordsMap["bankA"]))
+	}
+
+	// BA0001 is -6241250.16, which is below the maximum, BA0002 and BA0003 are not
+	if len(model.RecordWarnings) != 0 {
+		t.Errorf("Expected no warnings, got: %+v", model.RecordWarnings)
+	}
+
+	if err := CreateRecords("../csv/bankB_20250605.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.BankStatementRecordsMap["bankB"]) != 3 {
+		t.Errorf("Expected 3 bank statement records, got: %d", len(model.BankStatementRecordsMap["bankB"]))
+	}
+
+	if len(model.RecordWarnings) != 2 {
+		t.Errorf("Expected 2 warnings for amounts above the maximum, got: %+v", model.RecordWarnings)
+	}
+
+	if len(model.RejectedRecords) != 0 {
+		t.Errorf("Expected no rejected records, got: %+v", model.RejectedRecords)
+	}
+
+	if err := CreateRecords("../csv/bankC_20250605.csv", "bankStatement", "20250601", "20250630"); err != nil {
+		t.Errorf("Expected no error for valid record, but got: %v", err)
+	}
+
+	if len(model.RejectedRecords) != 3 || model.RejectedRecords[0].Reason != model.ReasonBadFormat {
+		t.Errorf("Expected 3 bad_format rejected records for identifiers not matching the pattern, got: %+v", model.RejectedRecords)
+	}
+
+	clearRecords()
+}
+
+func TestRules_WithInvalidRuleConfig(t *testing.T) {
+	if _, err := validator.NewRule(validator.RuleConfig{Field: "amount", Kind: "unknown"}); err == nil {
+		t.Errorf("Expected an error for unknown rule kind, but got nil")
+	}
+
+	if _, err := validator.NewRule(validator.RuleConfig{Field: "amount", Kind: validator.RuleEnum, Severity: "fatal", Values: []string{"a"}}); err == nil {
+		t.Errorf("Expected an error for unknown severity, but got nil")
+	}
+
+	if _, err := validator.NewRule(validator.RuleConfig{Field: "trxID", Kind: validator.RuleRegex, Pattern: "("}); err == nil {
+		t.Errorf("Expected an error for invalid pattern, but got nil")
+	}
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
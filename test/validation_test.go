@@ -0,0 +1,86 @@
+package test
+
+import (
+	"testing"
+
+	. "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/model"
+)
+
+func TestValidation_WithUsableFiles(t *testing.T) {
+
+	clearRecords()
+
+	validation := ValidateInputs([]string{"../csv/st_small.csv"}, []string{"../csv/bankA_20250605.csv"}, "20250601", "20250630", model.DataQualityThresholds{})
+
+	if len(validation.Files) != 2 {
+		t.Fatalf("Expected 2 validated files, got: %d", len(validation.Files))
+	}
+
+	for _, file := range validation.Files {
+		if file.Error != "" || file.Profile == nil {
+			t.Errorf("Expected %s to be loaded, got: %+v", file.FilePath, file)
+		}
+	}
+
+	if validation.Unusable() || validation.QualityFailed() {
+		t.Errorf("Expected the validation to pass, got: %+v", validation)
+	}
+
+	clearRecords()
+}
+
+func TestValidation_WithUnusableFile(t *testing.T) {
+
+	clearRecords()
+
+	validation := ValidateInputs([]string{"../csv/st_incorrect_header_column.csv", "../csv/st_small.csv"}, []string{"../csv/missing_20250605.csv"}, "20250601", "20250630", model.DataQualityThresholds{})
+
+	if len(validation.Files) != 3 {
+		t.Fatalf("Expected 3 validated files, got: %d", len(validation.Files))
+	}
+
+	if validation.Files[0].Error == "" || validation.Files[2].Error == "" {
+		t.Errorf("Expected errors for the wrong header and the missing file, got: %+v", validation.Files)
+	}
+
+	if validation.Files[1].Error != "" || validation.Files[1].Profile == nil {
+		t.Errorf("Expected the valid file to be loaded after the invalid one, got: %+v", validation.Files[1])
+	}
+
+	if !validation.Unusable() {
+		t.Errorf("Expected the validation to fail")
+	}
+
+	clearRecords()
+}
+
+func TestValidation_WithThresholdsCrossed(t *testing.T) {
+
+	clearRecords()
+
+	maxRejected, minRows := 50.0, 20
+	validation := ValidateInputs([]string{"../csv/st_small.csv"}, []string{"../csv/bankR_20250605_rejected.csv"}, "20250601", "20250630", model.DataQualityThresholds{
+		PerFile: model.QualityThresholds{MaxRejectedPct: &maxRejected},
+		Global:  model.QualityThresholds{MinRowCount: &minRows},
+	})
+
+	bankFile := validation.Files[1]
+	if len(bankFile.Rejected) != 4 {
+		t.Errorf("Expected 4 rejected rows for bankR, got: %d", len(bankFile.Rejected))
+	}
+
+	if len(bankFile.Breaches) != 1 || bankFile.Breaches[0].Threshold != ThresholdMaxRejectedPct {
+		t.Errorf("Expected bankR to break max_rejected_pct, got: %+v", bankFile.Breaches)
+	}
+
+	if len(validation.Breaches) != 1 || validation.Breaches[0].Threshold != ThresholdMinRowCount {
+		t.Errorf("Expected all files to break min_row_count, got: %+v", validation.Breaches)
+	}
+
+	if validation.Unusable() || !validation.QualityFailed() {
+		t.Errorf("Expected a data quality failure only, got: %+v", validation)
+	}
+
+	clearRecords()
+}
@@ -0,0 +1,251 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+var defaultCurrencySymbols = []string{"IDR", "Rp.", "Rp", "USD", "US$", "SGD", "S$", "EUR", "$", "€"}
+
+// AmountFormats are the named amount conventions that can be assigned to a bank profile
+var AmountFormats = map[string]model.AmountFormat{
+	// 1,234,567.89
+	"standard": {Name: "standard", DecimalSeparator: ".", ThousandSeparator: ",", CurrencySymbols: defaultCurrencySymbols},
+	// 1.234.567,89
+	"id": {Name: "id", DecimalSeparator: ",", ThousandSeparator: ".", CurrencySymbols: defaultCurrencySymbols},
+	// 1 234 567,89
+	"eu-space": {Name: "eu-space", DecimalSeparator: ",", ThousandSeparator: " ", CurrencySymbols: defaultCurrencySymbols},
+	// separators are inferred per value
+	"auto": {Name: "auto", CurrencySymbols: defaultCurrencySymbols},
+}
+
+var DefaultAmountFormat = AmountFormats["standard"]
+
+func GetAmountFormat(name string) (model.AmountFormat, error) {
+	format, ok := AmountFormats[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return model.AmountFormat{}, fmt.Errorf("unknown amount format %s", name)
+	}
+	return format, nil
+}
+
+// ParseAmount converts a raw amount as written in an export into a signed float.
+// It handles currency symbols, thousand separators, parentheses negatives and
+// trailing DR/CR or minus markers.
+func ParseAmount(raw string, format model.AmountFormat) (float64, error) {
+	value := strings.TrimSpace(strings.ReplaceAll(raw, "\u00a0", " "))
+	if value == "" {
+		return 0, fmt.Errorf("invalid amount %q: value is empty", raw)
+	}
+
+	negative := false
+	signMarkers := 0
+
+	// (1,000.00) is a negative amount
+	if strings.HasPrefix(value, "(") || strings.HasSuffix(value, ")") {
+		if !strings.HasPrefix(value, "(") || !strings.HasSuffix(value, ")") {
+			return 0, fmt.Errorf("invalid amount %q: unbalanced parentheses", raw)
+		}
+		value = strings.TrimSpace(value[1 : len(value)-1])
+		negative = true
+		signMarkers++
+	}
+
+	// The sign may be written before or after the currency symbol, e.g. -Rp 1.000 or Rp -1.000
+	value, leadingNegative, hasLeadingSign := trimLeadingSign(value)
+	value = trimCurrencySymbol(value, format.CurrencySymbols)
+	if !hasLeadingSign {
+		value, leadingNegative, hasLeadingSign = trimLeadingSign(value)
+	}
+	if hasLeadingSign {
+		negative = negative || leadingNegative
+		signMarkers++
+	}
+
+	// 1,000.00 DR / 1,000.00 CR / 1,000.00-
+	upper := strings.ToUpper(value)
+	switch {
+	case strings.HasSuffix(upper, "DR"):
+		value = strings.TrimSpace(value[:len(value)-2])
+		negative = true
+		signMarkers++
+	case strings.HasSuffix(upper, "CR"):
+		value = strings.TrimSpace(value[:len(value)-2])
+		signMarkers++
+	case strings.HasSuffix(value, "-"):
+		value = strings.TrimSpace(value[:len(value)-1])
+		negative = true
+		signMarkers++
+	}
+
+	if signMarkers > 1 {
+		return 0, fmt.Errorf("ambiguous amount %q: more than one sign marker", raw)
+	}
+
+	number, err := normalizeNumber(value, format)
+	if err != nil {
+		return 0, fmt.Errorf("%s amount %q: %v", amountErrorKind(err), raw, err)
+	}
+
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %v", raw, err)
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+func trimLeadingSign(value string) (string, bool, bool) {
+	if strings.HasPrefix(value, "-") {
+		return strings.TrimSpace(value[1:]), true, true
+	}
+	if strings.HasPrefix(value, "+") {
+		return strings.TrimSpace(value[1:]), false, true
+	}
+	return value, false, false
+}
+
+func trimCurrencySymbol(value string, symbols []string) string {
+	upper := strings.ToUpper(value)
+	for _, symbol := range symbols {
+		s := strings.ToUpper(symbol)
+		if strings.HasPrefix(upper, s) {
+			return strings.TrimSpace(value[len(symbol):])
+		}
+		if strings.HasSuffix(upper, s) {
+			return strings.TrimSpace(value[:len(value)-len(symbol)])
+		}
+	}
+	return value
+}
+
+type ambiguousAmountError struct {
+	message string
+}
+
+func (e *ambiguousAmountError) Error() string {
+	return e.message
+}
+
+func amountErrorKind(err error) string {
+	if _, ok := err.(*ambiguousAmountError); ok {
+		return "ambiguous"
+	}
+	return "invalid"
+}
+
+// normalizeNumber rewrites the digits and separators into a form accepted by strconv.ParseFloat
+func normalizeNumber(value string, format model.AmountFormat) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("no digits found")
+	}
+
+	decimal, thousand := format.DecimalSeparator, format.ThousandSeparator
+	if decimal == "" {
+		var err error
+		decimal, thousand, err = inferSeparators(value)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	integerPart, fractionPart := value, ""
+	if decimal != "" {
+		if strings.Count(value, decimal) > 1 {
+			return "", fmt.Errorf("decimal separator %q appears more than once", decimal)
+		}
+		if i := strings.Index(value, decimal); i >= 0 {
+			integerPart, fractionPart = value[:i], value[i+len(decimal):]
+		}
+	}
+
+	if thousand != "" && strings.Contains(integerPart, thousand) {
+		groups := strings.Split(integerPart, thousand)
+		for i, group := range groups {
+			if (i == 0 && (len(group) == 0 || len(group) > 3)) || (i > 0 && len(group) != 3) {
+				return "", fmt.Errorf("thousand separator %q is not grouping by three digits", thousand)
+			}
+		}
+		integerPart = strings.Join(groups, "")
+	}
+
+	if thousand != "" && strings.Contains(fractionPart, thousand) {
+		return "", fmt.Errorf("thousand separator %q found after decimal separator", thousand)
+	}
+
+	if (integerPart != "" || fractionPart == "") && !isDigits(integerPart) {
+		return "", fmt.Errorf("unexpected characters for format %s", formatName(format))
+	}
+
+	if fractionPart != "" && !isDigits(fractionPart) {
+		return "", fmt.Errorf("unexpected characters for format %s", formatName(format))
+	}
+
+	if fractionPart == "" {
+		return integerPart, nil
+	}
+	return integerPart + "." + fractionPart, nil
+}
+
+// inferSeparators guesses the separators of a value when the bank profile does not define them
+func inferSeparators(value string) (string, string, error) {
+	lastDot, lastComma := strings.LastIndex(value, "."), strings.LastIndex(value, ",")
+
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastDot > lastComma {
+			return ".", ",", nil
+		}
+		return ",", ".", nil
+	case lastDot < 0 && lastComma < 0:
+		return ".", "", nil
+	}
+
+	separator, last := ".", lastDot
+	if lastComma >= 0 {
+		separator, last = ",", lastComma
+	}
+
+	if strings.Count(value, separator) > 1 {
+		// 1.234.567 can only be thousands
+		if separator == "." {
+			return ",", ".", nil
+		}
+		return ".", ",", nil
+	}
+
+	// 1.234 could be either, 1234.567 cannot be thousands as their first group has at most three digits
+	if len(value)-last-1 == 3 && last >= 1 && last <= 3 {
+		return "", "", &ambiguousAmountError{
+			message: fmt.Sprintf("%q could be a decimal or a thousand separator", separator),
+		}
+	}
+
+	return separator, "", nil
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func formatName(format model.AmountFormat) string {
+	if format.Name == "" {
+		return "custom"
+	}
+	return format.Name
+}
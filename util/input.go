@@ -0,0 +1,285 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/sientong/reconciliation-service/model"
+)
+
+// sniffSize is the number of bytes looked at to detect the dialect of a file
+const sniffSize = 64 * 1024
+
+// Delimiters are the supported column delimiters, in order of preference when counts are equal
+var Delimiters = []rune{',', ';', '\t', '|'}
+
+// CSVFile is an input file decoded to utf-8 and read with its detected delimiter.
+// Header validation and row parsing both read files through it, so they always agree.
+type CSVFile struct {
+	*csv.Reader
+	Dialect model.Dialect
+	file    *os.File
+}
+
+func (f *CSVFile) Close() error {
+	return f.file.Close()
+}
+
+// OpenCSV opens a csv file after detecting its encoding, byte order mark and delimiter
+func OpenCSV(filePath string) (*CSVFile, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: no such file or directory", filePath)
+	}
+
+	buffered := bufio.NewReaderSize(file, sniffSize)
+	sample, err := buffered.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		file.Close()
+		return nil, fmt.Errorf("read %s: %w", filePath, err)
+	}
+
+	dialect := model.Dialect{}
+	dialect.Encoding, dialect.BOM = DetectEncoding(sample)
+
+	// Skip the byte order mark
+	if dialect.BOM {
+		bomSize := 2
+		if dialect.Encoding == model.EncodingUTF8 {
+			bomSize = 3
+		}
+		buffered.Discard(bomSize)
+		sample = sample[bomSize:]
+	}
+
+	decodedSample, _ := io.ReadAll(decode(bytes.NewReader(sample), dialect.Encoding))
+	delimiter, quoted := DetectDelimiter(decodedSample)
+	dialect.Delimiter, dialect.Quoted = string(delimiter), quoted
+
+	reader := csv.NewReader(decode(buffered, dialect.Encoding))
+	reader.Comma = delimiter
+
+	return &CSVFile{Reader: reader, Dialect: dialect, file: file}, nil
+}
+
+// DetectEncoding guesses the encoding of the start of a file and tells whether it starts with a byte order mark.
+// Files which are not valid utf-8 are read as Windows-1252.
+func DetectEncoding(sample []byte) (string, bool) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return model.EncodingUTF8, true
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return model.EncodingUTF16LE, true
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return model.EncodingUTF16BE, true
+	}
+
+	// Ascii text written as utf-16 without a byte order mark has a zero byte in every code unit
+	if len(sample) >= 2 {
+		evenZeros, oddZeros := 0, 0
+		for i, b := range sample {
+			if b != 0 {
+				continue
+			}
+			if i%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+		units := len(sample) / 2
+		if oddZeros > units/2 && evenZeros == 0 {
+			return model.EncodingUTF16LE, false
+		}
+		if evenZeros > units/2 && oddZeros == 0 {
+			return model.EncodingUTF16BE, false
+		}
+	}
+
+	// The sample may end in the middle of a rune
+	valid := sample
+	for i := 0; i < utf8.UTFMax-1 && len(valid) > 0 && !utf8.Valid(valid); i++ {
+		valid = valid[:len(valid)-1]
+	}
+	if utf8.Valid(valid) {
+		return model.EncodingUTF8, false
+	}
+	return model.EncodingWindows1252, false
+}
+
+// DetectDelimiter picks the delimiter found most often in the header line, outside of quotes.
+// It also tells whether the header has quoted fields. Comma is used when no delimiter is found.
+func DetectDelimiter(sample []byte) (rune, bool) {
+	counts := make(map[rune]int, len(Delimiters))
+	quoted, inQuotes := false, false
+
+	for _, r := range string(sample) {
+		if r == '"' {
+			quoted = true
+			inQuotes = !inQuotes
+			continue
+		}
+		if inQuotes {
+			continue
+		}
+		if r == '\n' || r == '\r' {
+			break
+		}
+		counts[r]++
+	}
+
+	delimiter := Delimiters[0]
+	for _, candidate := range Delimiters {
+		if counts[candidate] > counts[delimiter] {
+			delimiter = candidate
+		}
+	}
+	return delimiter, quoted
+}
+
+func decode(r io.Reader, encoding string) io.Reader {
+	switch encoding {
+	case model.EncodingUTF16LE:
+		return &utf16Reader{r: bufio.NewReader(r), littleEndian: true}
+	case model.EncodingUTF16BE:
+		return &utf16Reader{r: bufio.NewReader(r)}
+	case model.EncodingWindows1252:
+		return &windows1252Reader{r: r}
+	default:
+		return r
+	}
+}
+
+// utf16Reader converts utf-16 text to utf-8
+type utf16Reader struct {
+	r            *bufio.Reader
+	littleEndian bool
+	pending      []byte
+	// next is a unit read after a high surrogate which did not complete it, it is decoded by the next step
+	next    uint16
+	hasNext bool
+}
+
+func (d *utf16Reader) readUnit() (uint16, error) {
+	var unit [2]byte
+	if _, err := io.ReadFull(d.r, unit[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			// A trailing odd byte is dropped
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+	if d.littleEndian {
+		return uint16(unit[0]) | uint16(unit[1])<<8, nil
+	}
+	return uint16(unit[0])<<8 | uint16(unit[1]), nil
+}
+
+// readRune decodes the next rune. A high surrogate is only combined with a low surrogate following it, otherwise
+// it is U+FFFD and the unit after it, which may be a delimiter or a newline, is left for the next rune.
+func (d *utf16Reader) readRune() (rune, error) {
+	var unit uint16
+	if d.hasNext {
+		unit, d.hasNext = d.next, false
+	} else {
+		var err error
+		if unit, err = d.readUnit(); err != nil {
+			return 0, err
+		}
+	}
+
+	r := rune(unit)
+	if !utf16.IsSurrogate(r) {
+		return r, nil
+	}
+	if unit >= 0xDC00 {
+		// A low surrogate without a high one
+		return utf8.RuneError, nil
+	}
+
+	low, err := d.readUnit()
+	if err != nil {
+		return utf8.RuneError, nil
+	}
+	if low < 0xDC00 || low > 0xDFFF {
+		d.next, d.hasNext = low, true
+		return utf8.RuneError, nil
+	}
+	return utf16.DecodeRune(r, rune(low)), nil
+}
+
+func (d *utf16Reader) Read(p []byte) (int, error) {
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+
+	for n+utf8.UTFMax <= len(p) {
+		r, err := d.readRune()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		n += utf8.EncodeRune(p[n:], r)
+
+		if d.r.Buffered() == 0 && !d.hasNext {
+			// Do not block for more input when something was read
+			break
+		}
+	}
+
+	if n == 0 && len(p) > 0 {
+		// p is too small for a rune, keep the rest for the next read
+		r, err := d.readRune()
+		if err != nil {
+			return 0, err
+		}
+		d.pending = utf8.AppendRune(nil, r)
+		n = copy(p, d.pending)
+		d.pending = d.pending[n:]
+	}
+
+	return n, nil
+}
+
+// windows1252Table maps the bytes 0x80 to 0x9F, the other bytes are the same as Latin-1
+var windows1252Table = [32]rune{
+	'€', utf8.RuneError, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', utf8.RuneError, 'Ž', utf8.RuneError,
+	utf8.RuneError, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', utf8.RuneError, 'ž', 'Ÿ',
+}
+
+// windows1252Reader converts Windows-1252 text to utf-8
+type windows1252Reader struct {
+	r       io.Reader
+	buf     []byte
+	pending []byte
+}
+
+func (d *windows1252Reader) Read(p []byte) (int, error) {
+	if len(d.pending) == 0 {
+		if cap(d.buf) == 0 {
+			d.buf = make([]byte, 4096)
+		}
+		read, err := d.r.Read(d.buf[:min(max(len(p)/utf8.UTFMax, 1), len(d.buf))])
+		for _, b := range d.buf[:read] {
+			r := rune(b)
+			if b >= 0x80 && b <= 0x9F {
+				r = windows1252Table[b-0x80]
+			}
+			d.pending = utf8.AppendRune(d.pending, r)
+		}
+		if read == 0 {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sientong/reconciliation-service/cli"
+	impl "github.com/sientong/reconciliation-service/imp"
+	"github.com/sientong/reconciliation-service/report"
+)
+
+// runValidate checks that the input files are usable without reconciling them, it returns the exit status
+func runValidate(args []string) int {
+	flags := cli.NewFlagSet("validate", "[system.csv bank1.csv,bank2.csv [YYYYMMDD YYYYMMDD]]",
+		"Checks the header of every input file, parses all of their rows and checks the data quality thresholds,\n"+
+			"without reconciling them. Exits with 0 when the files are usable, 3 when a file cannot be used and\n"+
+			"4 when a data quality threshold is crossed.")
+	var in cli.InputFlags
+	var settings settingsFlags
+	in.Register(flags)
+	settings.register(flags)
+	settings.registerInputSettings(flags)
+	if status, ok := parseFlags(flags, args); !ok {
+		return status
+	}
+
+	inputs, err := in.Resolve(flags.Args(), time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	cfg, err := settings.load(flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	if err := applySettings(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitInputFailure
+	}
+
+	// Files already loaded by a previous run are reported, the store itself is never written by validate
+	if cfg.FingerprintStore != "" {
+		if err := impl.LoadFingerprintStore(cfg.FingerprintStore); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return exitInputFailure
+		}
+	}
+
+	impl.LogWriter = io.Discard
+	validation := impl.ValidateInputs(inputs.SystemFiles, inputs.BankFiles, inputs.Start, inputs.End, cfg.DataQuality)
+	report.WriteValidation(os.Stdout, validation)
+
+	switch {
+	case validation.Unusable():
+		return exitInputFailure
+	case validation.QualityFailed():
+		return exitDataQualityFailure
+	}
+	return exitSuccess
+}
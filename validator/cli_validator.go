@@ -13,11 +13,12 @@ func ValidateArgs(args []string) error {
 		return fmt.Errorf("insufficient arguments provided: Expected at least 4 arguments, got %d", len(args))
 	}
 
-	// Validate file extension
-	transactionFile := args[0]
-
-	if len(transactionFile) < 4 || transactionFile[len(transactionFile)-4:] != ".csv" {
-		return fmt.Errorf("invalid file format for system transactions: expected .csv, got %s", transactionFile)
+	// Validate file extension, of every file when several are separated by commas
+	transactionFiles := strings.Split(args[0], ",")
+	for _, transactionFile := range transactionFiles {
+		if len(transactionFile) < 4 || transactionFile[len(transactionFile)-4:] != ".csv" {
+			return fmt.Errorf("invalid file format for system transactions: expected .csv, got %s", transactionFile)
+		}
 	}
 
 	bankStatementFiles := strings.Split(args[1], ",")
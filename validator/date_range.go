@@ -0,0 +1,109 @@
+package validator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "20060102"
+
+// Periods are the names taken by --period
+var Periods = []string{"today", "yesterday", "this-month", "last-month"}
+
+// PeriodRange returns the start and end dates of a named period, relative to today
+func PeriodRange(period string, today time.Time) (string, string, error) {
+	year, month, day := today.Date()
+	today = time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+	firstOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, today.Location())
+
+	switch period {
+	case "today":
+		return formatRange(today, today)
+	case "yesterday":
+		yesterday := today.AddDate(0, 0, -1)
+		return formatRange(yesterday, yesterday)
+	case "this-month":
+		return formatRange(firstOfMonth, today)
+	case "last-month":
+		return formatRange(firstOfMonth.AddDate(0, -1, 0), firstOfMonth.AddDate(0, 0, -1))
+	}
+
+	return "", "", fmt.Errorf("invalid period %s, expected one of %s", period, strings.Join(Periods, ", "))
+}
+
+// LastRange returns the range of the last days or weeks before today, e.g. 7d or 2w. Today is left out
+// as its statements are not complete yet.
+func LastRange(last string, today time.Time) (string, string, error) {
+	unit := 1
+	count := last
+	switch {
+	case strings.HasSuffix(last, "d"):
+		count = strings.TrimSuffix(last, "d")
+	case strings.HasSuffix(last, "w"):
+		count, unit = strings.TrimSuffix(last, "w"), 7
+	default:
+		return "", "", fmt.Errorf("invalid range %s, expected a number of days or weeks such as 7d or 2w", last)
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return "", "", fmt.Errorf("invalid range %s, expected a number of days or weeks such as 7d or 2w", last)
+	}
+
+	year, month, day := today.Date()
+	end := time.Date(year, month, day, 0, 0, 0, 0, today.Location()).AddDate(0, 0, -1)
+	return formatRange(end.AddDate(0, 0, 1-n*unit), end)
+}
+
+// MonthRange returns the first and last dates of a month given as YYYY-MM
+func MonthRange(month string) (string, string, error) {
+	first, err := time.Parse("2006-01", month)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid month %s, expected YYYY-MM", month)
+	}
+	return formatRange(first, first.AddDate(0, 1, -1))
+}
+
+// InferDateRange returns the first and last statement dates found in bank file names such as bankName_YYYYMMDD.csv
+func InferDateRange(bankFiles []string) (string, string, error) {
+	var first, last string
+	for _, bankFile := range bankFiles {
+		date, err := StatementDate(bankFile)
+		if err != nil {
+			return "", "", err
+		}
+		if first == "" || date < first {
+			first = date
+		}
+		if last == "" || date > last {
+			last = date
+		}
+	}
+
+	if first == "" {
+		return "", "", fmt.Errorf("no bank statement file to infer the date range from")
+	}
+	return first, last, nil
+}
+
+// StatementDate returns the date of a bank statement file from its name, e.g. 20250605 for bankA_20250605.csv
+// or bankA_20250605_resent.csv
+func StatementDate(bankFile string) (string, error) {
+	name := strings.TrimSuffix(filepath.Base(bankFile), filepath.Ext(bankFile))
+	parts := strings.Split(name, "_")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("cannot infer the date range: %s is not named bankName_YYYYMMDD.csv", bankFile)
+	}
+
+	if _, err := time.Parse(dateLayout, parts[1]); err != nil {
+		return "", fmt.Errorf("cannot infer the date range: %s is not named bankName_YYYYMMDD.csv", bankFile)
+	}
+	return parts[1], nil
+}
+
+func formatRange(start time.Time, end time.Time) (string, string, error) {
+	return start.Format(dateLayout), end.Format(dateLayout), nil
+}
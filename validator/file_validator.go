@@ -1,28 +1,47 @@
 package validator
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strings"
+	"io"
+
+	"github.com/sientong/reconciliation-service/util"
 )
 
 var internalTransactionHeader = []string{"trxID", "amount", "type", "transactionTime"}
 var bankStatementHeader = []string{"unique_identifier", "amount", "date"}
 
+// Bank statement layouts, the signed amount layout is the default one
+const (
+	BankLayoutSignedAmount = "signed_amount"
+	BankLayoutDebitCredit  = "debit_credit"
+	BankLayoutIndicator    = "amount_indicator"
+)
+
+// bankStatementLayoutOrder is the order in which a header is tried against the layouts, the layouts
+// with more columns first so a header with amount and dc columns is not read as a signed amount
+var bankStatementLayoutOrder = []string{BankLayoutIndicator, BankLayoutDebitCredit, BankLayoutSignedAmount}
+
+var bankStatementLayouts = map[string][]string{
+	BankLayoutSignedAmount: bankStatementHeader,
+	BankLayoutDebitCredit:  {"unique_identifier", "debit", "credit", "date"},
+	BankLayoutIndicator:    {"unique_identifier", "amount", "dc", "date"},
+}
+
 func ValidateFile(filePath string, fileType string) error {
-	var file, err = os.OpenFile(filePath, os.O_RDONLY, 0644)
+	file, err := util.OpenCSV(filePath)
 	if err != nil {
-		return fmt.Errorf("open %s: no such file or directory", filePath)
+		return err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	if condition := scanner.Scan(); !condition {
+	header, err := file.Read()
+	if err == io.EOF {
 		return fmt.Errorf("file %s is empty", filePath)
 	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", filePath, err)
+	}
 
-	header := strings.Split(scanner.Text(), ",")
 	if err := validateHeader(header, fileType); err != nil {
 		return fmt.Errorf("invalid header in %s: %v", filePath, err)
 	}
@@ -31,29 +50,15 @@ func ValidateFile(filePath string, fileType string) error {
 }
 
 func validateHeader(header []string, fileType string) error {
-	switch fileType {
-	case "systemTransaction":
-		if len(header) != len(internalTransactionHeader) {
-			return fmt.Errorf("expected %d columns, got %d", len(internalTransactionHeader), len(header))
-		}
-		for i, col := range header {
-			if col != internalTransactionHeader[i] {
-				return fmt.Errorf("expected column %s, got %s", internalTransactionHeader[i], col)
-			}
-		}
-		return nil
-	case "bankStatement":
-		if len(header) != len(bankStatementHeader) {
-			return fmt.Errorf("expected %d columns, got %d", len(bankStatementHeader), len(header))
-		}
-		for i, col := range header {
-			if col != bankStatementHeader[i] {
-				return fmt.Errorf("expected column %s, got %s", bankStatementHeader[i], col)
-			}
-		}
-		return nil
-	default:
-		return fmt.Errorf("unknown file type: %s", fileType)
-	}
+	_, err := MapHeader(header, fileType)
+	return err
+}
 
+// BankStatementLayout returns which bank statement layout the header belongs to
+func BankStatementLayout(header []string) (string, error) {
+	mapped, err := MapHeader(header, "bankStatement")
+	if err != nil {
+		return "", err
+	}
+	return mapped.Layout, nil
 }
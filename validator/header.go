@@ -0,0 +1,156 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HeaderCaseSensitive makes column names match only when written with the same case
+var HeaderCaseSensitive = false
+
+// ColumnAliases are the other names accepted for a known column
+var ColumnAliases = map[string][]string{
+	"trxID":             {"trx_id", "transaction_id"},
+	"transactionTime":   {"transaction_time"},
+	"unique_identifier": {"unique_id"},
+}
+
+// Header maps the columns of a file onto the known columns of its record type, by name
+type Header struct {
+	// Layout is the bank statement layout, it is empty for system transactions
+	Layout string
+	// Columns are the indexes of the known columns, keyed by their canonical name
+	Columns map[string]int
+	// Extra are the indexes of the unknown columns, keyed by their name as written. A name written again is
+	// keyed with its occurrence, e.g. note#2.
+	Extra map[string]int
+	Width int
+}
+
+// AddColumnAliases adds other names accepted for a known column
+func AddColumnAliases(column string, aliases []string) error {
+	if !IsKnownColumn(column) {
+		return fmt.Errorf("unknown column %s", column)
+	}
+
+	for _, alias := range aliases {
+		ColumnAliases[column] = append(ColumnAliases[column], strings.TrimSpace(alias))
+	}
+	return nil
+}
+
+// MapHeader finds the known columns of a header by name or alias, in any order. Every column of the
+// record type, or of one of the bank statement layouts, must be present; other columns are extra.
+func MapHeader(header []string, fileType string) (Header, error) {
+	switch fileType {
+	case "systemTransaction":
+		return mapColumns(header, internalTransactionHeader)
+	case "bankStatement":
+		var defaultErr error
+		for _, layout := range bankStatementLayoutOrder {
+			mapped, err := mapColumns(header, bankStatementLayouts[layout])
+			if err == nil {
+				mapped.Layout = layout
+				return mapped, nil
+			}
+			if layout == BankLayoutSignedAmount {
+				// Report the difference against the default layout
+				defaultErr = err
+			}
+		}
+		return Header{}, defaultErr
+	default:
+		return Header{}, fmt.Errorf("unknown file type: %s", fileType)
+	}
+}
+
+func mapColumns(header []string, expected []string) (Header, error) {
+	mapped := Header{Columns: make(map[string]int, len(expected)), Width: len(header)}
+
+	for i, name := range header {
+		column, known := canonicalColumn(strings.TrimSpace(name), expected)
+		if !known {
+			if mapped.Extra == nil {
+				mapped.Extra = make(map[string]int)
+			}
+			mapped.Extra[extraColumnKey(name, mapped.Extra)] = i
+			continue
+		}
+
+		if _, exists := mapped.Columns[column]; exists {
+			return Header{}, fmt.Errorf("column %s appears more than once", column)
+		}
+		mapped.Columns[column] = i
+	}
+
+	for _, column := range expected {
+		if _, exists := mapped.Columns[column]; exists {
+			continue
+		}
+
+		// The unknown columns are likely misspelled known ones
+		var unknown []string
+		for _, name := range header {
+			if _, extra := mapped.Extra[name]; extra {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			return Header{}, fmt.Errorf("missing column %s, unknown columns: %s", column, strings.Join(unknown, ", "))
+		}
+		return Header{}, fmt.Errorf("missing column %s", column)
+	}
+
+	return mapped, nil
+}
+
+// extraColumnKey returns the key of an unknown column, made unique when the name was already seen
+func extraColumnKey(name string, extra map[string]int) string {
+	key := name
+	for occurrence := 2; ; occurrence++ {
+		if _, exists := extra[key]; !exists {
+			return key
+		}
+		key = name + "#" + strconv.Itoa(occurrence)
+	}
+}
+
+// canonicalColumn returns which of the expected columns a header name stands for
+func canonicalColumn(name string, expected []string) (string, bool) {
+	for _, column := range expected {
+		if sameColumnName(name, column) {
+			return column, true
+		}
+		for _, alias := range ColumnAliases[column] {
+			if sameColumnName(name, alias) {
+				return column, true
+			}
+		}
+	}
+	return "", false
+}
+
+func sameColumnName(name string, column string) bool {
+	if HeaderCaseSensitive {
+		return name == column
+	}
+	return strings.EqualFold(name, column)
+}
+
+// IsKnownColumn tells whether a column is one of the system transaction or bank statement columns
+func IsKnownColumn(column string) bool {
+	for _, known := range internalTransactionHeader {
+		if column == known {
+			return true
+		}
+	}
+	for _, layout := range bankStatementLayouts {
+		for _, known := range layout {
+			if column == known {
+				return true
+			}
+		}
+	}
+	return false
+}
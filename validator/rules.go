@@ -0,0 +1,282 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sientong/reconciliation-service/model"
+	"github.com/sientong/reconciliation-service/util"
+)
+
+const (
+	SeverityReject = "reject"
+	SeverityWarn   = "warn"
+)
+
+// Rule kinds
+const (
+	RuleRequired    = "required"
+	RuleNumber      = "number"
+	RuleAmount      = "amount"
+	RuleEnum        = "enum"
+	RuleAmountRange = "amount_range"
+	RuleDateFormat  = "date_format"
+	RuleRegex       = "regex"
+)
+
+// RuleConfig is the configurable description of a rule
+type RuleConfig struct {
+	Field         string   `json:"field"`
+	Kind          string   `json:"kind"`
+	Severity      string   `json:"severity,omitempty"`
+	Values        []string `json:"values,omitempty"`
+	CaseSensitive bool     `json:"case_sensitive,omitempty"`
+	Pattern       string   `json:"pattern,omitempty"`
+	Min           *float64 `json:"min,omitempty"`
+	Max           *float64 `json:"max,omitempty"`
+}
+
+type Rule struct {
+	Name     string
+	Field    string
+	Severity string
+	Reason   string
+	check    func(value string, format model.AmountFormat) error
+}
+
+// Violation is a rule that did not pass for a record
+type Violation struct {
+	Field    string
+	Rule     string
+	Severity string
+	Reason   string
+	Message  string
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+var defaultRuleConfigs = map[string][]RuleConfig{
+	"systemTransaction": {
+		{Field: "trxID", Kind: RuleRequired},
+		{Field: "amount", Kind: RuleRequired},
+		{Field: "type", Kind: RuleRequired},
+		{Field: "transactionTime", Kind: RuleRequired},
+		{Field: "amount", Kind: RuleNumber},
+		{Field: "amount", Kind: RuleAmountRange, Min: floatPtr(0), Severity: SeverityWarn},
+		{Field: "type", Kind: RuleEnum, Values: []string{model.DirectionCredit, model.DirectionDebit}},
+		{Field: "transactionTime", Kind: RuleDateFormat, Values: []string{time.RFC3339}},
+		{Field: "trxID", Kind: RuleRegex, Pattern: `^[A-Za-z0-9_\-./]+$`, Severity: SeverityWarn},
+	},
+	"bankStatement": {
+		{Field: "unique_identifier", Kind: RuleRequired},
+		{Field: "date", Kind: RuleRequired},
+		{Field: "amount", Kind: RuleRequired},
+		{Field: "dc", Kind: RuleRequired},
+		{Field: "amount", Kind: RuleAmount},
+		{Field: "debit", Kind: RuleAmount},
+		{Field: "credit", Kind: RuleAmount},
+		{Field: "dc", Kind: RuleEnum, Values: []string{"D", "C", "DR", "CR", "DB", "DEBIT", "CREDIT"}},
+		{Field: "date", Kind: RuleDateFormat, Values: []string{"2006-01-02"}},
+		{Field: "unique_identifier", Kind: RuleRegex, Pattern: `^[A-Za-z0-9_\-./]+$`, Severity: SeverityWarn},
+	},
+}
+
+// RuleSets holds the active rules per record type
+var RuleSets = mustBuildRuleSets(defaultRuleConfigs)
+
+func mustBuildRuleSets(configs map[string][]RuleConfig) map[string][]Rule {
+	ruleSets, err := BuildRuleSets(configs)
+	if err != nil {
+		panic(err)
+	}
+	return ruleSets
+}
+
+// BuildRuleSets turns rule configs keyed by record type into rules
+func BuildRuleSets(configs map[string][]RuleConfig) (map[string][]Rule, error) {
+	ruleSets := make(map[string][]Rule, len(configs))
+	for recordType, ruleConfigs := range configs {
+		if recordType != "systemTransaction" && recordType != "bankStatement" {
+			return nil, fmt.Errorf("unknown record type: %s", recordType)
+		}
+
+		for _, config := range ruleConfigs {
+			rule, err := NewRule(config)
+			if err != nil {
+				return nil, fmt.Errorf("%s rule for %s: %w", recordType, config.Field, err)
+			}
+			ruleSets[recordType] = append(ruleSets[recordType], rule)
+		}
+	}
+	return ruleSets, nil
+}
+
+// LoadRuleSets replaces the rules of the record types found in a json file
+func LoadRuleSets(filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", filePath, err)
+	}
+
+	var configs map[string][]RuleConfig
+	if err := json.Unmarshal(content, &configs); err != nil {
+		return fmt.Errorf("parse %s: %w", filePath, err)
+	}
+
+	return ConfigureRules(configs)
+}
+
+// ConfigureRules replaces the rules of the given record types, the other record types keep their rules
+func ConfigureRules(configs map[string][]RuleConfig) error {
+	ruleSets, err := BuildRuleSets(configs)
+	if err != nil {
+		return err
+	}
+
+	for recordType, rules := range ruleSets {
+		RuleSets[recordType] = rules
+	}
+	return nil
+}
+
+func NewRule(config RuleConfig) (Rule, error) {
+	if config.Field == "" {
+		return Rule{}, fmt.Errorf("field is required")
+	}
+
+	severity := strings.ToLower(config.Severity)
+	if severity == "" {
+		severity = SeverityReject
+	}
+	if severity != SeverityReject && severity != SeverityWarn {
+		return Rule{}, fmt.Errorf("invalid severity %s, expected '%s' or '%s'", config.Severity, SeverityReject, SeverityWarn)
+	}
+
+	rule := Rule{Name: config.Kind, Field: config.Field, Severity: severity}
+	field := config.Field
+
+	switch config.Kind {
+	case RuleRequired:
+		rule.Reason = model.ReasonMissingField
+		rule.check = func(value string, _ model.AmountFormat) error {
+			if strings.TrimSpace(value) == "" {
+				return fmt.Errorf("%s is required", field)
+			}
+			return nil
+		}
+
+	case RuleNumber:
+		rule.Reason = model.ReasonBadAmount
+		rule.check = func(value string, _ model.AmountFormat) error {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("%s %s is not a number", field, value)
+			}
+			return nil
+		}
+
+	case RuleAmount:
+		rule.Reason = model.ReasonBadAmount
+		rule.check = func(value string, format model.AmountFormat) error {
+			if _, err := util.ParseAmount(value, format); err != nil {
+				return fmt.Errorf("%s: %w", field, err)
+			}
+			return nil
+		}
+
+	case RuleAmountRange:
+		if config.Min == nil && config.Max == nil {
+			return Rule{}, fmt.Errorf("%s rule needs min or max", config.Kind)
+		}
+		rule.Reason = model.ReasonOutOfRange
+		rule.check = func(value string, format model.AmountFormat) error {
+			amount, err := util.ParseAmount(value, format)
+			if err != nil {
+				// Unparseable amounts are reported by the type rules
+				return nil
+			}
+			if config.Min != nil && amount < *config.Min {
+				return fmt.Errorf("%s %s is below the minimum %.2f", field, value, *config.Min)
+			}
+			if config.Max != nil && amount > *config.Max {
+				return fmt.Errorf("%s %s is above the maximum %.2f", field, value, *config.Max)
+			}
+			return nil
+		}
+
+	case RuleEnum:
+		if len(config.Values) == 0 {
+			return Rule{}, fmt.Errorf("%s rule needs values", config.Kind)
+		}
+		rule.Reason = model.ReasonBadType
+		rule.check = func(value string, _ model.AmountFormat) error {
+			for _, allowed := range config.Values {
+				if value == allowed || (!config.CaseSensitive && strings.EqualFold(strings.TrimSpace(value), allowed)) {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s %s is not one of %s", field, value, strings.Join(config.Values, ", "))
+		}
+
+	case RuleDateFormat:
+		if len(config.Values) == 0 {
+			return Rule{}, fmt.Errorf("%s rule needs at least one layout in values", config.Kind)
+		}
+		rule.Reason = model.ReasonBadDate
+		rule.check = func(value string, _ model.AmountFormat) error {
+			for _, layout := range config.Values {
+				if _, err := time.Parse(layout, value); err == nil {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s %s does not match %s", field, value, strings.Join(config.Values, " or "))
+		}
+
+	case RuleRegex:
+		pattern, err := regexp.Compile(config.Pattern)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid pattern %s: %w", config.Pattern, err)
+		}
+		rule.Reason = model.ReasonBadFormat
+		rule.check = func(value string, _ model.AmountFormat) error {
+			if !pattern.MatchString(value) {
+				return fmt.Errorf("%s %s does not match %s", field, value, config.Pattern)
+			}
+			return nil
+		}
+
+	default:
+		return Rule{}, fmt.Errorf("unknown rule kind %s", config.Kind)
+	}
+
+	return rule, nil
+}
+
+// Apply runs the rule against the fields of a record. Fields that are not part of the record are skipped,
+// and only the required rule checks empty values.
+func (r Rule) Apply(fields map[string]string, format model.AmountFormat) *Violation {
+	value, exists := fields[r.Field]
+	if !exists {
+		return nil
+	}
+	if r.Name != RuleRequired && strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	if err := r.check(value, format); err != nil {
+		return &Violation{
+			Field:    r.Field,
+			Rule:     r.Name,
+			Severity: r.Severity,
+			Reason:   r.Reason,
+			Message:  err.Error(),
+		}
+	}
+	return nil
+}